@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/diegodario88/importador-cep-correios/pkg/diff"
+	"github.com/diegodario88/importador-cep-correios/pkg/edne"
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+	"github.com/diegodario88/importador-cep-correios/pkg/utils"
+)
+
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	with := flags.String("with", "", "schema (postgres) ou arquivo (sqlite) com a nova versão da base")
+	withSource := flags.String("with-source", "", "diretório ou arquivo .zip da nova base eDNE/basico, no lugar de -with")
+	tableNames := flags.String("table", allTables, "tabelas a comparar, separadas por vírgula, ou all para todas")
+	output := flags.String("output", "", "arquivo JSONL com as alterações (padrão: diff/<base>_<nova>.jsonl)")
+	storageOpts := addStorageFlags(flags)
+	flags.Parse(args)
+
+	if (*with == "") == (*withSource == "") {
+		log.Fatal("Informe a nova versão com -with ou -with-source")
+	}
+
+	tables, err := exportTables(*tableNames)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// A cep_enderecos é montada na importação e não existe nos arquivos.
+	if *withSource != "" {
+		isCepEnderecos := func(table types.Table) bool {
+			return table.Name == types.CepEnderecosTable.Name
+		}
+
+		if *tableNames != allTables && slices.ContainsFunc(tables, isCepEnderecos) {
+			log.Fatal("A tabela cep_enderecos só pode ser comparada com -with")
+		}
+		tables = slices.DeleteFunc(tables, isCepEnderecos)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	storage := storageOpts.new()
+	if err := storage.Connect(); err != nil {
+		log.Fatal(err)
+	}
+	defer storage.Disconnect()
+
+	baseName := *storageOpts.schema
+	if *storageOpts.driver == sqliteStorage {
+		baseName = strings.TrimSuffix(filepath.Base(*storageOpts.sqlitePath), filepath.Ext(*storageOpts.sqlitePath))
+	}
+
+	var target diff.RowSource
+	var targetName string
+	if *withSource != "" {
+		src, err := edne.Open(*withSource)
+		if err != nil {
+			storage.Disconnect()
+			log.Fatal(err)
+		}
+		defer src.Close()
+
+		target = diff.SourceRows(src.FS)
+		targetName = strings.TrimSuffix(filepath.Base(*withSource), filepath.Ext(*withSource))
+	} else {
+		other := storageOpts.newAt(*with)
+		if err := other.Connect(); err != nil {
+			storage.Disconnect()
+			log.Fatal(err)
+		}
+		defer other.Disconnect()
+
+		target = other.StreamTable
+		targetName = strings.TrimSuffix(filepath.Base(*with), filepath.Ext(*with))
+	}
+
+	if *output == "" {
+		*output = filepath.Join(utils.GetCWD(), "diff", fmt.Sprintf("%s_%s.jsonl", baseName, targetName))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(*output), 0o755); err != nil {
+		storage.Disconnect()
+		log.Fatalf("erro ao criar diretório %s: %v", filepath.Dir(*output), err)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		storage.Disconnect()
+		log.Fatalf("erro ao criar arquivo %s: %v", *output, err)
+	}
+	defer file.Close()
+
+	buffer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(buffer)
+	emit := func(change diff.Change) error {
+		return encoder.Encode(change)
+	}
+
+	start := time.Now()
+	var summaries []diff.Summary
+	for _, table := range tables {
+		log.Printf("Comparando %s", table.Name)
+
+		summary, err := diff.Table(ctx, table, storage.StreamTable, target, emit)
+		if err != nil {
+			storage.Disconnect()
+			log.Fatal(err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if err := buffer.Flush(); err != nil {
+		storage.Disconnect()
+		log.Fatalf("erro ao gravar arquivo %s: %v", *output, err)
+	}
+
+	var inseridos, removidos, alterados int
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Println("\nAlterações por tabela:")
+	fmt.Fprintln(writer, "Tabela\tInseridos\tRemovidos\tAlterados\tColunas alteradas")
+	for _, summary := range summaries {
+		inseridos += summary.Inseridos
+		removidos += summary.Removidos
+		alterados += summary.Alterados
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n",
+			summary.Table,
+			utils.FormatNumber(summary.Inseridos),
+			utils.FormatNumber(summary.Removidos),
+			utils.FormatNumber(summary.Alterados),
+			formatColumns(summary.Colunas),
+		)
+	}
+	writer.Flush()
+
+	fmt.Printf("\nInseridos: %s | Removidos: %s | Alterados: %s | Tempo total: %s\n",
+		utils.FormatNumber(inseridos),
+		utils.FormatNumber(removidos),
+		utils.FormatNumber(alterados),
+		time.Since(start).Round(time.Millisecond),
+	)
+	fmt.Printf("Arquivo de alterações: %s\n", *output)
+}
+
+func formatColumns(columns map[string]int) string {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s (%s)", name, utils.FormatNumber(columns[name]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/diegodario88/importador-cep-correios/pkg/export"
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+	"github.com/diegodario88/importador-cep-correios/pkg/utils"
+)
+
+const allTables = "all"
+
+func runExport(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	tableNames := flags.String("table", types.CepEnderecosTable.Name, "tabelas a exportar, separadas por vírgula, ou all para todas as tabelas do eDNE")
+	format := flags.String("format", export.JSONL, "formato dos arquivos: jsonl, csv ou parquet")
+	output := flags.String("output", filepath.Join(utils.GetCWD(), "export"), "diretório de destino dos arquivos")
+	partitionUF := flags.Bool("partition-uf", false, "gera um arquivo por UF para as tabelas que possuem coluna de UF")
+	storageOpts := addStorageFlags(flags)
+	flags.Parse(args)
+
+	switch *format {
+	case export.JSONL, export.CSV, export.PARQUET:
+	default:
+		log.Fatalf("Formato de exportação desconhecido: %s", *format)
+	}
+
+	tables, err := exportTables(*tableNames)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	storage := storageOpts.new()
+	if err := storage.Connect(); err != nil {
+		log.Fatal(err)
+	}
+	defer storage.Disconnect()
+
+	start := time.Now()
+	var reports []export.FileReport
+	for _, table := range tables {
+		log.Printf("Exportando %s (%s)", table.Name, *format)
+
+		tableReports, err := export.Table(ctx, storage, table, *format, *output, *partitionUF)
+		if err != nil {
+			storage.Disconnect()
+			log.Fatal(err)
+		}
+		reports = append(reports, tableReports...)
+	}
+
+	var totalLines int
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Arquivo\tLinhas")
+	for _, report := range reports {
+		totalLines += report.Lines
+		fmt.Fprintf(writer, "%s\t%s\n", report.File, utils.FormatNumber(report.Lines))
+	}
+	writer.Flush()
+
+	fmt.Printf("\nArquivos: %d | Linhas: %s | Tempo total: %s\n", len(reports), utils.FormatNumber(totalLines), time.Since(start).Round(time.Millisecond))
+}
+
+func exportTables(names string) ([]types.Table, error) {
+	if names == allTables {
+		return append([]types.Table{types.CepEnderecosTable}, types.CorreiosTables...), nil
+	}
+
+	var tables []types.Table
+	for _, name := range strings.Split(names, ",") {
+		table, ok := types.TableByName(strings.TrimSpace(strings.ToLower(name)))
+		if !ok {
+			return nil, fmt.Errorf("tabela desconhecida: %s", name)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vbauerster/mpb/v8"
+	"github.com/vbauerster/mpb/v8/decor"
+
+	immu "github.com/diegodario88/importador-cep-correios/pkg/constants"
+	"github.com/diegodario88/importador-cep-correios/pkg/dump"
+	"github.com/diegodario88/importador-cep-correios/pkg/edne"
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+	"github.com/diegodario88/importador-cep-correios/pkg/utils"
+	work "github.com/diegodario88/importador-cep-correios/pkg/workers"
+)
+
+func runImport(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	source := flags.String("source", "", "diretório ou arquivo .zip da base eDNE (padrão: eDNE/basico, ou eDNE/delta com -delta)")
+	delta := flags.Bool("delta", false, "aplica os arquivos DELTA_*.TXT da base eDNE_Delta sobre as tabelas existentes")
+	versao := flags.String("versao", "", "versão da base eDNE (detectada automaticamente quando omitida)")
+	force := flags.Bool("force", false, "reimporta a base mesmo que a versão já conste em importacao_relatorio")
+	storageOpts := addStorageFlags(flags)
+	dryRun := flags.Bool("dry-run", false, "apenas valida os arquivos da base, sem acessar o banco de dados")
+	strictIntegrity := flags.Bool("strict-integrity", false, "aborta a importação se houver registros órfãos entre as tabelas")
+	foreignKeys := flags.Bool("fk", false, "cria chaves estrangeiras entre as tabelas após a carga (implica -strict-integrity)")
+	concurrentIndexes := flags.Bool("concurrent-indexes", false, "cria os índices secundários com CREATE INDEX CONCURRENTLY (postgres)")
+	history := flags.Bool("history", false, "mantém em cep_historico as versões anteriores de cada CEP, com o período de validade")
+	dumpEnabled := flags.Bool("dump", false, "gera o dump do schema importado (formato custom do pg_dump) ao final da importação")
+	dumpDir := flags.String("dump-dir", filepath.Join(utils.GetCWD(), "dump"), "diretório de destino do dump")
+	workers := flags.Int("workers", 0, "quantidade máxima de arquivos processados em paralelo (0 = sem limite)")
+	batchSize := flags.Int("batch-size", immu.ONE_THOUSAND_BATCH_SIZE, "quantidade de linhas por lote de inserção")
+	refreshOnly := flags.Bool("refresh-cep-enderecos", false, "apenas reconstrói cep_enderecos a partir das tabelas atuais (ex: após falha na atualização de um delta)")
+	files := flags.String("files", "", "arquivos a importar, separados por vírgula (ex: LOG_LOCALIDADE.TXT,LOG_LOGRADOURO_*.TXT); as demais tabelas são mantidas")
+	flags.Parse(args)
+
+	if *files != "" && *delta {
+		log.Fatal("A flag -files não pode ser usada com -delta")
+	}
+
+	jobs, keptTables, err := selectJobs(*files)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tipo, tasks, title := immu.IMPORTACAO_BASICO, len(jobs), "Importação base eDNE Correios "
+	if *delta {
+		tipo, tasks, title = immu.IMPORTACAO_DELTA, 1, "Atualização delta eDNE Correios "
+	}
+
+	if *source == "" {
+		*source = filepath.Join(utils.GetCWD(), "eDNE", tipo)
+	}
+
+	// Cada arquivo em processamento mantém uma conexão durante o COPY, mais
+	// uma para as consultas de controle da importação.
+	if *storageOpts.maxConns == 0 && *workers > 0 {
+		*storageOpts.maxConns = *workers + 1
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	var lineCount int64
+	storage := storageOpts.new()
+	signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(signalCtx)
+	defer cancel()
+	counterChan := make(chan types.Counter)
+	progress := mpb.New(mpb.WithWidth(64))
+
+	if *refreshOnly {
+		if err := storage.Connect(); err != nil {
+			log.Fatal(err)
+		}
+		defer storage.Disconnect()
+
+		log.Println("Reconstruindo cep_enderecos a partir das tabelas atuais")
+		if err := storage.RefreshCepEnderecos(signalCtx); err != nil {
+			storage.Disconnect()
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *dumpEnabled && *storageOpts.driver != postgresStorage {
+		log.Fatal("A flag -dump está disponível apenas com -storage postgres")
+	}
+
+	if *foreignKeys && *storageOpts.driver != postgresStorage {
+		log.Fatal("A flag -fk está disponível apenas com -storage postgres")
+	}
+
+	src, err := edne.Open(*source)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer src.Close()
+
+	// A validação só lê os arquivos, então não depende da versão da base.
+	if *dryRun {
+		log.Printf("Validando base eDNE em %s (%s)", src.Path, tipo)
+		valid := validateSource(src.FS, *delta)
+		src.Close()
+
+		if !valid {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *versao == "" {
+		detected, err := edne.DetectVersion(src)
+		switch {
+		case errors.Is(err, edne.ErrVersionNotFound):
+			log.Printf("%v, a importação será registrada como versão %s (informe a versão com -versao)", err, immu.VERSAO_DESCONHECIDA)
+			*versao = immu.VERSAO_DESCONHECIDA
+		case err != nil:
+			log.Fatal(err)
+		default:
+			*versao = detected
+		}
+	}
+
+	if err := storage.Connect(); err != nil {
+		log.Fatal(err)
+	}
+	defer storage.Disconnect()
+
+	if err := storage.CreateCorreiosSchema(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Sem versão conhecida não há como identificar uma reimportação.
+	if *versao != immu.VERSAO_DESCONHECIDA {
+		imported, err := storage.ExistsImportacaoVersao(tipo, *versao)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if imported && !*force {
+			log.Fatalf("A versão %s (%s) da base eDNE já foi importada, use -force para reimportar", *versao, tipo)
+		}
+	}
+
+	log.Printf("Importando base eDNE versão %s (%s)", *versao, tipo)
+
+	if !*delta {
+		if err := storage.CreateCorreiosSql(); err != nil {
+			log.Fatal(err)
+		}
+
+		if err := storage.CopyCorreiosTables(keptTables); err != nil {
+			storage.DiscardCorreiosSql()
+			log.Fatal(err)
+		}
+	}
+
+	var workerSlots chan struct{}
+	if *workers > 0 {
+		workerSlots = make(chan struct{}, *workers)
+	}
+
+	bar := progress.New(int64(tasks),
+		mpb.BarStyle().Lbound("╢").Filler("▌").Tip("▌").Padding("░").Rbound("╟"),
+		mpb.BarFillerOnComplete(""),
+		mpb.PrependDecorators(
+			decor.Name(title),
+			decor.OnComplete(
+				decor.Spinner(nil, decor.WCSyncSpace), "importado",
+			),
+		),
+		mpb.AppendDecorators(decor.Percentage()),
+	)
+
+	run := func(fileName string, execute types.Processes) {
+		defer wg.Done()
+		defer bar.Increment()
+
+		tools := types.JobTools{
+			Ctx:         ctx,
+			Database:    storage,
+			Source:      src.FS,
+			CounterChan: counterChan,
+			BatchSize:   *batchSize,
+			Workers:     workerSlots,
+		}
+
+		execute(fileName, tools)
+	}
+
+	wg.Add(tasks)
+	if *delta {
+		go run("DELTA_*.TXT", work.Delta)
+	} else {
+		for _, job := range jobs {
+			go run(job.file, job.process)
+		}
+	}
+
+	go func() {
+		wg.Wait()
+		close(counterChan)
+	}()
+
+	var arquivos []types.ImportacaoArquivo
+	record := func(relatorio types.ImportacaoRelatorio) {
+		id, err := storage.InsertImportacaoRelatorio(relatorio)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		slices.SortFunc(arquivos, func(a, b types.ImportacaoArquivo) int {
+			return strings.Compare(a.Arquivo, b.Arquivo)
+		})
+		if err := storage.InsertImportacaoArquivos(id, arquivos); err != nil {
+			log.Println(err)
+		}
+	}
+
+	// No modo delta a carga parcial já foi descartada pelo rollback da
+	// transação do delta.
+	abort := func(situacao string, failure error) {
+		if !*delta {
+			if err := storage.DiscardCorreiosSql(); err != nil {
+				log.Println(err)
+			}
+		}
+
+		record(types.ImportacaoRelatorio{
+			Tipo:        tipo,
+			Situacao:    situacao,
+			VersaoEDNE:  *versao,
+			Duracao:     time.Since(start).Round(time.Millisecond),
+			Observacoes: fmt.Sprintf("Importação %s após %d linhas: %v. Executada por: %s", situacao, lineCount, failure, utils.GetHostname()),
+		})
+
+		storage.Disconnect()
+		src.Close()
+		os.Exit(1)
+	}
+
+	var failure error
+	for result := range counterChan {
+		if result.Arquivo != nil {
+			arquivos = append(arquivos, *result.Arquivo)
+		}
+
+		if result.Error != nil && failure == nil {
+			failure = result.Error
+			cancel()
+		}
+
+		lineCount += int64(result.Increment)
+	}
+
+	progress.Wait()
+
+	if failure != nil {
+		if signalCtx.Err() != nil {
+			log.Println("Importação interrompida, descartando carga parcial")
+			abort(immu.IMPORTACAO_CANCELADA, failure)
+		}
+
+		log.Printf("Erro no processamento: %v", failure)
+		abort(immu.IMPORTACAO_FALHA, failure)
+	}
+
+	// O sinal continua tratado até a troca: as etapas seguintes usam o mesmo
+	// contexto e, se interrompidas, a carga é descartada como cancelada.
+	fail := func(err error) {
+		if signalCtx.Err() != nil {
+			log.Printf("Importação interrompida (%v), descartando carga parcial", err)
+			abort(immu.IMPORTACAO_CANCELADA, err)
+		}
+
+		log.Println(err)
+		abort(immu.IMPORTACAO_FALHA, err)
+	}
+
+	var indexes []types.IndexBuild
+	var indexDuration time.Duration
+	if !*delta {
+		if err := storage.CreateCepEnderecos(ctx); err != nil {
+			fail(err)
+		}
+
+		indexStart := time.Now()
+		indexes, err = storage.CreateIndexes(ctx, *concurrentIndexes)
+		if err != nil {
+			fail(err)
+		}
+		indexDuration = time.Since(indexStart).Round(time.Millisecond)
+
+		orphans, err := checkIntegrity(storage)
+		if err != nil {
+			fail(fmt.Errorf("erro na verificação de integridade: %w", err))
+		}
+
+		if orphans > 0 && (*strictIntegrity || *foreignKeys) {
+			err := fmt.Errorf("%s registros órfãos encontrados", utils.FormatNumber(orphans))
+			log.Printf("Integridade referencial violada: %v", err)
+			abort(immu.IMPORTACAO_FALHA, err)
+		}
+
+		if *foreignKeys {
+			if err := storage.CreateForeignKeys(); err != nil {
+				fail(err)
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			fail(err)
+		}
+
+		if err := storage.SwapCorreiosSchema(); err != nil {
+			fail(err)
+		}
+	} else {
+		// O delta já foi confirmado, então cep_enderecos é reconstruída até o
+		// fim mesmo após um sinal, para não ficar fora de sincronia.
+		// Uma falha aqui não desfaz o delta: a execução fica registrada como
+		// pendente, o que também impede reaplicar a mesma versão.
+		if err := storage.RefreshCepEnderecos(context.Background()); err != nil {
+			log.Printf("O delta foi aplicado, mas cep_enderecos não foi atualizada: %v", err)
+			log.Println("Reconstrua cep_enderecos com: importer import -refresh-cep-enderecos")
+
+			record(types.ImportacaoRelatorio{
+				Tipo:        tipo,
+				Situacao:    immu.IMPORTACAO_PENDENTE,
+				VersaoEDNE:  *versao,
+				Duracao:     time.Since(start).Round(time.Millisecond),
+				Observacoes: fmt.Sprintf("Delta aplicado (%d linhas), mas cep_enderecos não foi atualizada: %v. Execute import -refresh-cep-enderecos. Executada por: %s", lineCount, err, utils.GetHostname()),
+			})
+
+			storage.Disconnect()
+			src.Close()
+			os.Exit(1)
+		}
+	}
+
+	stop()
+
+	var abertos, encerrados int
+	if *history {
+		abertos, encerrados, err = storage.UpdateCepHistorico(*versao)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	fmt.Println("\nRelatório final:")
+
+	duration := time.Since(start).Round(time.Millisecond)
+	totalRecords, _ := storage.GetTotalRecords()
+	totalCeps, _ := storage.GetTotalCEPs()
+
+	record(types.ImportacaoRelatorio{
+		Tipo:           tipo,
+		Situacao:       immu.IMPORTACAO_CONCLUIDA,
+		TotalRegistros: totalRecords,
+		TotalCeps:      totalCeps,
+		VersaoEDNE:     *versao,
+		Duracao:        duration,
+		Observacoes:    fmt.Sprintf("Importação realizada por: %s", utils.GetHostname()),
+	})
+
+	fmt.Printf("Registros totais: %s\n", utils.FormatNumber(totalRecords))
+	fmt.Printf("Total de CEPs: %s\n", utils.FormatNumber(totalCeps))
+	fmt.Printf("Total de linhas: %s\n", utils.FormatNumber(int(lineCount)))
+	fmt.Printf("Tempo total: %s\n", duration)
+
+	if *history {
+		fmt.Printf("Histórico de CEPs: %s versões abertas, %s encerradas\n", utils.FormatNumber(abertos), utils.FormatNumber(encerrados))
+	}
+
+	fmt.Println("\nArquivos processados:")
+	printArquivos(arquivos)
+
+	if len(indexes) > 0 {
+		printIndexBuilds(indexes, indexDuration)
+	}
+
+	if *dumpEnabled {
+		dumpPath, preRestorePath, err := dump.Export(storageOpts.connString(), *storageOpts.schema, *versao, *dumpDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Dump gerado: %s\n", dumpPath)
+		fmt.Printf("Pré-restauração: %s\n", preRestorePath)
+	}
+}
+
+type importJob struct {
+	file    string
+	process types.Processes
+}
+
+var basicoJobs = []importJob{
+	{"ECT_PAIS.TXT", work.Single},
+	{"LOG_FAIXA_UF.TXT", work.Single},
+	{"LOG_LOCALIDADE.TXT", work.Single},
+	{"LOG_VAR_LOC.TXT", work.Single},
+	{"LOG_FAIXA_LOCALIDADE.TXT", work.Single},
+	{"LOG_BAIRRO.TXT", work.Single},
+	{"LOG_VAR_BAI.TXT", work.Single},
+	{"LOG_FAIXA_BAIRRO.TXT", work.Single},
+	{"LOG_CPC.TXT", work.Single},
+	{"LOG_FAIXA_CPC.TXT", work.Single},
+	{"LOG_LOGRADOURO_*.TXT", work.Multiple},
+	{"LOG_VAR_LOG.TXT", work.Single},
+	{"LOG_NUM_SEC.TXT", work.Single},
+	{"LOG_GRANDE_USUARIO.TXT", work.Single},
+	{"LOG_UNID_OPER.TXT", work.Single},
+	{"LOG_FAIXA_UOP.TXT", work.Single},
+}
+
+// Sem -files todos os arquivos são importados; caso contrário, retorna os
+// arquivos selecionados e as tabelas que devem ser mantidas da base atual.
+func selectJobs(files string) ([]importJob, []types.Table, error) {
+	if files == "" {
+		return basicoJobs, nil, nil
+	}
+
+	selected := make(map[string]bool)
+	for _, fileName := range strings.Split(files, ",") {
+		table, ok := types.TableForFile(strings.TrimSpace(fileName))
+		if !ok {
+			return nil, nil, fmt.Errorf("arquivo desconhecido: %s", fileName)
+		}
+		selected[table.Name] = true
+	}
+
+	var jobs []importJob
+	var kept []types.Table
+	for _, job := range basicoJobs {
+		table, _ := types.TableForFile(job.file)
+		if selected[table.Name] {
+			jobs = append(jobs, job)
+		} else {
+			kept = append(kept, table)
+		}
+	}
+	return jobs, kept, nil
+}
+
+func printIndexBuilds(builds []types.IndexBuild, total time.Duration) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Println("\nÍndices secundários:")
+	fmt.Fprintln(writer, "Tabela\tÍndice\tDuração")
+
+	for _, build := range builds {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", build.Table, build.Index, build.Duracao)
+	}
+	writer.Flush()
+
+	fmt.Printf("Índices criados: %d | Tempo de criação: %s\n", len(builds), total)
+}
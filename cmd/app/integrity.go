@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+	"github.com/diegodario88/importador-cep-correios/pkg/utils"
+)
+
+func checkIntegrity(storage types.Storage) (int, error) {
+	issues, err := storage.CheckIntegrity()
+	if err != nil {
+		return 0, err
+	}
+
+	var totalOrphans int
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Println("\nIntegridade referencial:")
+	fmt.Fprintln(writer, "Tabela\tColuna\tReferência\tÓrfãos")
+
+	for _, issue := range issues {
+		totalOrphans += issue.Orphans
+		fmt.Fprintf(writer, "%s\t%s\t%s.%s\t%s\n",
+			issue.Table,
+			issue.Reference.Column,
+			issue.Reference.Table,
+			issue.Reference.TargetColumn,
+			utils.FormatNumber(issue.Orphans),
+		)
+	}
+	writer.Flush()
+
+	fmt.Printf("Referências verificadas: %d | Órfãos: %s\n\n", len(issues), utils.FormatNumber(totalOrphans))
+	return totalOrphans, nil
+}
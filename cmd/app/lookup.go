@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	immu "github.com/diegodario88/importador-cep-correios/pkg/constants"
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+	"github.com/diegodario88/importador-cep-correios/pkg/utils"
+)
+
+func runLookup(args []string) {
+	flags := flag.NewFlagSet("lookup", flag.ExitOnError)
+	uf := flags.String("uf", "", "sigla da UF para a busca por endereço")
+	localidade := flags.String("localidade", "", "nome da localidade para a busca por endereço")
+	logradouro := flags.String("logradouro", "", "nome (ou parte do nome) do logradouro para a busca por endereço")
+	numero := flags.Int("numero", 0, "número do endereço, para obter o CEP exato de logradouros seccionados")
+	limite := flags.Int("limite", immu.BUSCA_LIMITE_PADRAO, "quantidade máxima de resultados da busca por endereço")
+	data := flags.String("data", "", "data (AAAA-MM-DD) para consultar o CEP como estava em cep_historico")
+	storageOpts := addStorageFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: importer lookup [flags] <cep>\n   ou: importer lookup [flags] -logradouro <nome>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 && *logradouro == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	storage := storageOpts.new()
+	if err := storage.Connect(); err != nil {
+		log.Fatal(err)
+	}
+	defer storage.Disconnect()
+
+	var result any
+	var err error
+
+	switch {
+	case flags.NArg() > 0:
+		cep, ok := utils.NormalizeCep(flags.Arg(0))
+		if !ok {
+			storage.Disconnect()
+			log.Fatalf("CEP inválido, informe 8 dígitos: %s", flags.Arg(0))
+		}
+
+		if *data != "" {
+			date, parseErr := time.Parse(time.DateOnly, *data)
+			if parseErr != nil {
+				storage.Disconnect()
+				log.Fatalf("Data inválida, informe no formato AAAA-MM-DD: %s", *data)
+			}
+
+			result, err = storage.GetCepHistorico(cep, date)
+			break
+		}
+
+		var notFound *types.CepNotFoundError
+		result, err = storage.GetCep(cep)
+		if errors.As(err, &notFound) {
+			result, err = storage.GetCepFaixa(cep)
+		}
+	case *numero > 0:
+		result, err = storage.GetCepNumero(types.BuscaNumero{
+			UF:         *uf,
+			Localidade: *localidade,
+			Logradouro: *logradouro,
+			Numero:     *numero,
+		})
+	default:
+		result, err = storage.SearchCep(types.BuscaCep{
+			UF:         *uf,
+			Localidade: *localidade,
+			Logradouro: *logradouro,
+			Limite:     *limite,
+		})
+	}
+
+	if err != nil {
+		storage.Disconnect()
+		log.Fatal(err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		log.Printf("Erro ao serializar resposta: %v", err)
+	}
+}
@@ -1,116 +1,55 @@
 package main
 
 import (
-	"context"
 	"fmt"
-	"log"
-	"path/filepath"
-	"sync"
-	"time"
-
-	"github.com/vbauerster/mpb/v8"
-	"github.com/vbauerster/mpb/v8/decor"
-
-	immu "github.com/diegodario88/importador-cep-correios/pkg/constants"
-	"github.com/diegodario88/importador-cep-correios/pkg/db"
-	"github.com/diegodario88/importador-cep-correios/pkg/types"
-	"github.com/diegodario88/importador-cep-correios/pkg/utils"
-	work "github.com/diegodario88/importador-cep-correios/pkg/workers"
+	"os"
+	"strings"
 )
 
-func main() {
-	start := time.Now()
-	var wg sync.WaitGroup
-	var lineCount int64
-	var storage types.Storage = &db.DB{}
-	basePath := filepath.Join(utils.GetCWD(), "eDNE", "basico")
-	ctx := context.Background()
-	counterChan := make(chan types.Counter)
-	progress := mpb.New(mpb.WithWidth(64))
-
-	if err := storage.Connect(); err != nil {
-		log.Fatal(err)
-	}
-	defer storage.Disconnect()
-
-	if err := storage.CreateCorreiosSql(); err != nil {
-		log.Fatal(err)
-	}
+const usage = `Uso: importer [comando] [flags]
 
-	bar := progress.New(int64(immu.SIXTEEN_TASKS),
-		mpb.BarStyle().Lbound("╢").Filler("▌").Tip("▌").Padding("░").Rbound("╟"),
-		mpb.BarFillerOnComplete(""),
-		mpb.PrependDecorators(
-			decor.Name("Importação base eDNE Correios "),
-			decor.OnComplete(
-				decor.Spinner(nil, decor.WCSyncSpace), "importado",
-			),
-		),
-		mpb.AppendDecorators(decor.Percentage()),
-	)
+Comandos:
+  import     importa a base eDNE (padrão quando nenhum comando é informado)
+  validate   valida os arquivos da base eDNE sem acessar o banco de dados
+  lookup     consulta um CEP ou busca CEPs pelo endereço
+  serve      sobe o serviço HTTP de consulta de CEP
+  export     exporta a base importada para JSONL, CSV ou Parquet
+  report     lista o histórico de importações
+  diff       compara duas versões da base e lista as alterações
 
-	run := func(fileName string, execute types.Processes) {
-		defer wg.Done()
-		defer bar.Increment()
+Use "importer [comando] -h" para ver as flags de cada comando.
+`
 
-		tools := types.JobTools{
-			Ctx:         ctx,
-			Database:    storage,
-			BasePath:    basePath,
-			CounterChan: counterChan,
+func main() {
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") {
+		if len(os.Args) > 1 && (os.Args[1] == "-h" || os.Args[1] == "-help" || os.Args[1] == "--help") {
+			fmt.Print(usage)
+			return
 		}
 
-		execute(fileName, tools)
+		runImport(os.Args[1:])
+		return
 	}
 
-	wg.Add(immu.SIXTEEN_TASKS)
-	go run("ECT_PAIS.TXT", work.Single)
-	go run("LOG_FAIXA_UF.TXT", work.Single)
-	go run("LOG_LOCALIDADE.TXT", work.Single)
-	go run("LOG_VAR_LOC.TXT", work.Single)
-	go run("LOG_FAIXA_LOCALIDADE.TXT", work.Single)
-	go run("LOG_BAIRRO.TXT", work.Single)
-	go run("LOG_VAR_BAI.TXT", work.Single)
-	go run("LOG_FAIXA_BAIRRO.TXT", work.Single)
-	go run("LOG_CPC.TXT", work.Single)
-	go run("LOG_FAIXA_CPC.TXT", work.Single)
-	go run("LOG_LOGRADOURO_*.TXT", work.Multiple)
-	go run("LOG_VAR_LOG.TXT", work.Single)
-	go run("LOG_NUM_SEC.TXT", work.Single)
-	go run("LOG_GRANDE_USUARIO.TXT", work.Single)
-	go run("LOG_UNID_OPER.TXT", work.Single)
-	go run("LOG_FAIXA_UOP.TXT", work.Single)
-
-	go func() {
-		wg.Wait()
-		close(counterChan)
-	}()
-
-	for result := range counterChan {
-		if result.Error != nil {
-			log.Fatalf("Erro no processamento: %v", result.Error)
-		}
-
-		lineCount += int64(result.Increment)
+	switch os.Args[1] {
+	case "import":
+		runImport(os.Args[2:])
+	case "validate":
+		runValidate(os.Args[2:])
+	case "lookup":
+		runLookup(os.Args[2:])
+	case "serve":
+		runServe(os.Args[2:])
+	case "export":
+		runExport(os.Args[2:])
+	case "report":
+		runReport(os.Args[2:])
+	case "diff":
+		runDiff(os.Args[2:])
+	case "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Comando desconhecido: %s\n\n%s", os.Args[1], usage)
+		os.Exit(2)
 	}
-
-	progress.Wait()
-	fmt.Println("\nRelatório final:")
-
-	duration := time.Since(start).Round(time.Millisecond)
-	totalRecords, _ := storage.GetTotalRecords()
-	totalCeps, _ := storage.GetTotalCEPs()
-
-	storage.InsertImportacaoRelatorio(types.ImportacaoRelatorio{
-		TotalRegistros: totalRecords,
-		TotalCeps:      totalCeps,
-		VersaoEDNE:     "25041", //TODO: Essa info deve vir dinâmica do arquivo dos correios .zip
-		Duracao:        duration,
-		Observacoes:    fmt.Sprintf("Importação realizada por: %s", utils.GetHostname()),
-	})
-
-	fmt.Printf("Registros totais: %s\n", utils.FormatNumber(totalRecords))
-	fmt.Printf("Total de CEPs: %s\n", utils.FormatNumber(totalCeps))
-	fmt.Printf("Total de linhas: %s\n", utils.FormatNumber(int(lineCount)))
-	fmt.Printf("Tempo total: %s\n", duration)
 }
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+	"github.com/diegodario88/importador-cep-correios/pkg/utils"
+)
+
+func runReport(args []string) {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	limite := flags.Int("limite", 20, "quantidade de execuções exibidas, da mais recente para a mais antiga")
+	id := flags.Int("id", 0, "exibe o relatório por arquivo da execução informada")
+	diff := flags.Int("diff", 0, "compara, arquivo a arquivo, a execução de -id com a execução informada")
+	storageOpts := addStorageFlags(flags)
+	flags.Parse(args)
+
+	storage := storageOpts.new()
+	if err := storage.Connect(); err != nil {
+		log.Fatal(err)
+	}
+	defer storage.Disconnect()
+
+	if err := storage.CreateCorreiosSchema(); err != nil {
+		storage.Disconnect()
+		log.Fatal(err)
+	}
+
+	if *diff > 0 && *id == 0 {
+		storage.Disconnect()
+		log.Fatal("A flag -diff exige -id")
+	}
+
+	if *id > 0 {
+		arquivos, err := storage.ListImportacaoArquivos(*id)
+		if err != nil {
+			storage.Disconnect()
+			log.Fatal(err)
+		}
+
+		if *diff == 0 {
+			printArquivos(arquivos)
+			return
+		}
+
+		anteriores, err := storage.ListImportacaoArquivos(*diff)
+		if err != nil {
+			storage.Disconnect()
+			log.Fatal(err)
+		}
+
+		printArquivosDiff(anteriores, arquivos)
+		return
+	}
+
+	reports, err := storage.ListImportacaoRelatorio(*limite)
+	if err != nil {
+		storage.Disconnect()
+		log.Fatal(err)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tExecutado em\tTipo\tSituação\tVersão\tRegistros\tCEPs\tDuração\tObservações")
+	for _, report := range reports {
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			report.ID,
+			report.ExecutadoEm.Format("2006-01-02 15:04:05"),
+			report.Tipo,
+			report.Situacao,
+			report.VersaoEDNE,
+			utils.FormatNumber(report.TotalRegistros),
+			utils.FormatNumber(report.TotalCeps),
+			report.Duracao,
+			report.Observacoes,
+		)
+	}
+	writer.Flush()
+}
+
+func printArquivos(arquivos []types.ImportacaoArquivo) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Arquivo\tLinhas\tRegistros\tBytes\tDuração\tChecksum\tErro")
+	for _, arquivo := range arquivos {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			arquivo.Arquivo,
+			utils.FormatNumber(arquivo.Linhas),
+			utils.FormatNumber(arquivo.Registros),
+			utils.FormatNumber(int(arquivo.Bytes)),
+			arquivo.Duracao,
+			arquivo.Checksum,
+			arquivo.Erro,
+		)
+	}
+	writer.Flush()
+}
+
+// Arquivos presentes em apenas uma das execuções aparecem com o lado ausente
+// zerado; o checksum indica se o conteúdo do arquivo mudou.
+func printArquivosDiff(anteriores []types.ImportacaoArquivo, atuais []types.ImportacaoArquivo) {
+	byName := make(map[string][2]*types.ImportacaoArquivo)
+	var names []string
+	for i, lista := range [][]types.ImportacaoArquivo{anteriores, atuais} {
+		for j := range lista {
+			pair, ok := byName[lista[j].Arquivo]
+			if !ok {
+				names = append(names, lista[j].Arquivo)
+			}
+			pair[i] = &lista[j]
+			byName[lista[j].Arquivo] = pair
+		}
+	}
+	slices.Sort(names)
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Arquivo\tRegistros antes\tRegistros depois\tDiferença\tConteúdo")
+	for _, name := range names {
+		pair := byName[name]
+		var antes, depois int
+		situacao := "alterado"
+
+		switch {
+		case pair[0] == nil:
+			situacao = "novo"
+		case pair[1] == nil:
+			situacao = "removido"
+		case pair[0].Checksum != "" && pair[0].Checksum == pair[1].Checksum:
+			situacao = "igual"
+		}
+
+		if pair[0] != nil {
+			antes = pair[0].Registros
+		}
+		if pair[1] != nil {
+			depois = pair[1].Registros
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%+d\t%s\n",
+			name,
+			utils.FormatNumber(antes),
+			utils.FormatNumber(depois),
+			depois-antes,
+			situacao,
+		)
+	}
+	writer.Flush()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/diegodario88/importador-cep-correios/pkg/server"
+)
+
+func runServe(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	storageOpts := addStorageFlags(flags)
+	flags.Parse(args)
+
+	storage := storageOpts.new()
+
+	if err := storage.Connect(); err != nil {
+		log.Fatal(err)
+	}
+	defer storage.Disconnect()
+
+	port := os.Getenv("SERVER_PORT")
+	if port == "" {
+		port = "3000"
+	}
+
+	srv := server.New(storage)
+	log.Printf("Servidor de consulta de CEP ouvindo na porta %s", port)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := srv.ListenAndServe(ctx, ":"+port); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Println("Servidor de consulta de CEP encerrado")
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/diegodario88/importador-cep-correios/pkg/db"
+	"github.com/diegodario88/importador-cep-correios/pkg/sqlite"
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+)
+
+const (
+	postgresStorage = "postgres"
+	sqliteStorage   = "sqlite"
+)
+
+type storageOptions struct {
+	driver     *string
+	sqlitePath *string
+	dsn        *string
+	schema     *string
+	maxConns   *int
+}
+
+func addStorageFlags(flags *flag.FlagSet) storageOptions {
+	return storageOptions{
+		driver:     flags.String("storage", postgresStorage, "backend de armazenamento: postgres ou sqlite"),
+		sqlitePath: flags.String("sqlite-path", "correios.db", "arquivo do banco SQLite (com -storage sqlite)"),
+		dsn:        flags.String("dsn", "", "string de conexão do PostgreSQL, URL ou chave=valor (padrão: DATABASE_URL ou variáveis do arquivo .env)"),
+		schema:     flags.String("schema", db.DefaultSchema, "schema do PostgreSQL onde a base é carregada e consultada"),
+		maxConns:   flags.Int("max-conns", 0, "máximo de conexões no pool do PostgreSQL (0 = automático)"),
+	}
+}
+
+func (o storageOptions) connString() string {
+	if *o.dsn != "" {
+		return *o.dsn
+	}
+	return db.ConnString()
+}
+
+func (o storageOptions) new() types.Storage {
+	switch *o.driver {
+	case postgresStorage:
+		return &db.DB{DSN: *o.dsn, Schema: *o.schema, MaxConns: *o.maxConns}
+	case sqliteStorage:
+		return &sqlite.DB{Path: *o.sqlitePath}
+	default:
+		log.Fatalf("Backend de armazenamento desconhecido: %s", *o.driver)
+		return nil
+	}
+}
+
+// Mesmo backend e conexão, apontando para outro schema (postgres) ou outro
+// arquivo (sqlite).
+func (o storageOptions) newAt(location string) types.Storage {
+	switch *o.driver {
+	case postgresStorage:
+		return &db.DB{DSN: *o.dsn, Schema: location, MaxConns: *o.maxConns}
+	case sqliteStorage:
+		return &sqlite.DB{Path: location}
+	default:
+		log.Fatalf("Backend de armazenamento desconhecido: %s", *o.driver)
+		return nil
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/tabwriter"
+
+	immu "github.com/diegodario88/importador-cep-correios/pkg/constants"
+	"github.com/diegodario88/importador-cep-correios/pkg/edne"
+	"github.com/diegodario88/importador-cep-correios/pkg/utils"
+	"github.com/diegodario88/importador-cep-correios/pkg/validate"
+)
+
+func runValidate(args []string) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	source := flags.String("source", "", "diretório ou arquivo .zip da base eDNE (padrão: eDNE/basico, ou eDNE/delta com -delta)")
+	delta := flags.Bool("delta", false, "valida os arquivos DELTA_*.TXT da base eDNE_Delta")
+	flags.Parse(args)
+
+	tipo := immu.IMPORTACAO_BASICO
+	if *delta {
+		tipo = immu.IMPORTACAO_DELTA
+	}
+
+	if *source == "" {
+		*source = filepath.Join(utils.GetCWD(), "eDNE", tipo)
+	}
+
+	src, err := edne.Open(*source)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	valid := validateSource(src.FS, *delta)
+	src.Close()
+
+	if !valid {
+		os.Exit(1)
+	}
+}
+
+func validateSource(source fs.FS, delta bool) bool {
+	files, err := validate.Files(source, delta)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	reports := make([]validate.FileReport, len(files))
+	errs := make([]error, len(files))
+
+	for i, fileName := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reports[i], errs[i] = validate.File(context.Background(), source, fileName)
+		}()
+	}
+	wg.Wait()
+
+	valid := true
+	var totalLines, totalErrors int
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Arquivo\tLinhas\tErros")
+
+	for i, report := range reports {
+		if errs[i] != nil {
+			log.Printf("Erro ao validar %s: %v", report.File, errs[i])
+			valid = false
+			continue
+		}
+
+		totalLines += report.Lines
+		totalErrors += report.Errors
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", report.File, utils.FormatNumber(report.Lines), utils.FormatNumber(report.Errors))
+	}
+	writer.Flush()
+
+	for _, report := range reports {
+		if report.Errors == 0 {
+			continue
+		}
+
+		valid = false
+		fmt.Printf("\n%s (%s erros, exibindo até %d):\n", report.File, utils.FormatNumber(report.Errors), len(report.Samples))
+		for _, sample := range report.Samples {
+			fmt.Printf("  %s\n", sample)
+		}
+	}
+
+	fmt.Printf("\nArquivos: %d | Linhas: %s | Erros: %s\n", len(reports), utils.FormatNumber(totalLines), utils.FormatNumber(totalErrors))
+	return valid
+}
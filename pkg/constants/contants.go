@@ -1,7 +1,50 @@
 package constants
 
+import "time"
+
 const (
-	SIXTEEN_TASKS           = 16
-	EITHTEEN_TASKS          = 18
 	ONE_THOUSAND_BATCH_SIZE = 1000
 )
+
+const (
+	CONNECT_MAX_ATTEMPTS       = 6
+	CONNECT_RETRY_INITIAL_WAIT = 500 * time.Millisecond
+	CONNECT_RETRY_MAX_WAIT     = 8 * time.Second
+)
+
+const (
+	SERVER_READ_TIMEOUT     = 5 * time.Second
+	SERVER_WRITE_TIMEOUT    = 10 * time.Second
+	SERVER_IDLE_TIMEOUT     = 60 * time.Second
+	SERVER_SHUTDOWN_TIMEOUT = 10 * time.Second
+)
+
+const (
+	IMPORTACAO_BASICO = "basico"
+	IMPORTACAO_DELTA  = "delta"
+)
+
+const VERSAO_DESCONHECIDA = "desconhecida"
+
+const (
+	IMPORTACAO_CONCLUIDA = "concluida"
+	IMPORTACAO_CANCELADA = "cancelada"
+	IMPORTACAO_FALHA     = "falha"
+	// Delta confirmado, mas cep_enderecos não foi reconstruída.
+	IMPORTACAO_PENDENTE = "pendente"
+)
+
+const (
+	BUSCA_LIMITE_PADRAO = 20
+	BUSCA_LIMITE_MAXIMO = 100
+)
+
+const (
+	DELTA_INSERT = "INS"
+	DELTA_UPDATE = "UPD"
+	DELTA_DELETE = "DEL"
+)
+
+// Linhas ordenadas em memória por vez ao ordenar os arquivos de uma tabela
+// para o diff; blocos maiores são gravados em arquivos temporários.
+const DIFF_SORT_CHUNK_SIZE = 100_000
@@ -2,11 +2,17 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	immu "github.com/diegodario88/importador-cep-correios/pkg/constants"
 	"github.com/diegodario88/importador-cep-correios/pkg/types"
@@ -16,33 +22,57 @@ import (
 	_ "github.com/lib/pq"
 )
 
+const (
+	DefaultSchema = "correios"
+	stagingSuffix = "_staging"
+	retiredSuffix = "_antigo"
+)
+
+// O nome do schema é interpolado nas DDLs, por isso apenas identificadores
+// simples são aceitos; o sufixo do staging precisa caber em 63 caracteres.
+var schemaPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,54}$`)
+
 type DB struct {
-	pool *pgxpool.Pool
-	ctx  context.Context
+	DSN      string
+	Schema   string
+	MaxConns int
+	pool     *pgxpool.Pool
+	ctx      context.Context
 }
 
 func (db *DB) Connect() error {
 	db.ctx = context.Background()
 
+	if !schemaPattern.MatchString(db.schema()) {
+		return fmt.Errorf("nome de schema inválido: %q", db.schema())
+	}
+
 	err := godotenv.Load(".env")
 	if err != nil {
 		log.Println("Warning: Error loading .env file:", err)
 	}
 
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
-		os.Getenv("POSTGRES_USER"),
-		os.Getenv("POSTGRES_PASSWORD"),
-		os.Getenv("POSTGRESQL_HOST"),
-		os.Getenv("POSTGRESQL_PORT"),
-		os.Getenv("POSTGRES_DB"))
+	dsn := db.DSN
+	if dsn == "" {
+		dsn = ConnString()
+	}
 
-	pool, err := pgxpool.New(db.ctx, connStr)
+	config, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
-		return fmt.Errorf("error connecting to database: %w", err)
+		return fmt.Errorf("error parsing connection string: %w", err)
 	}
 
-	err = pool.Ping(db.ctx)
+	if db.MaxConns > 0 {
+		config.MaxConns = int32(db.MaxConns)
+	}
+
+	pool, err := pgxpool.NewWithConfig(db.ctx, config)
 	if err != nil {
+		return fmt.Errorf("error connecting to database: %w", err)
+	}
+
+	if err := pingWithRetry(db.ctx, pool); err != nil {
+		pool.Close()
 		return fmt.Errorf("error pinging database: %w", err)
 	}
 
@@ -58,6 +88,68 @@ func (db *DB) Connect() error {
 	return nil
 }
 
+// Aguarda o banco aceitar conexões (ex: container ainda iniciando), dobrando
+// o intervalo entre as tentativas.
+func pingWithRetry(ctx context.Context, pool *pgxpool.Pool) error {
+	wait := immu.CONNECT_RETRY_INITIAL_WAIT
+	for attempt := 1; ; attempt++ {
+		err := pool.Ping(ctx)
+		if err == nil || attempt == immu.CONNECT_MAX_ATTEMPTS {
+			return err
+		}
+
+		log.Printf("Banco indisponível (tentativa %d de %d): %v, nova tentativa em %s",
+			attempt, immu.CONNECT_MAX_ATTEMPTS, err, wait)
+		time.Sleep(wait)
+		wait = min(wait*2, immu.CONNECT_RETRY_MAX_WAIT)
+	}
+}
+
+// DATABASE_URL aceita tanto URL quanto o formato chave=valor da libpq; sem
+// ela, a URL é montada a partir das variáveis POSTGRES*.
+func ConnString() string {
+	if dsn := os.Getenv("DATABASE_URL"); dsn != "" {
+		return dsn
+	}
+
+	connURL := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(os.Getenv("POSTGRES_USER"), os.Getenv("POSTGRES_PASSWORD")),
+		Host:   net.JoinHostPort(os.Getenv("POSTGRESQL_HOST"), os.Getenv("POSTGRESQL_PORT")),
+		Path:   "/" + os.Getenv("POSTGRES_DB"),
+	}
+
+	params := url.Values{}
+	for env, param := range map[string]string{
+		"POSTGRES_SSLMODE":     "sslmode",
+		"POSTGRES_SSLROOTCERT": "sslrootcert",
+		"POSTGRES_SSLCERT":     "sslcert",
+		"POSTGRES_SSLKEY":      "sslkey",
+	} {
+		if value := os.Getenv(env); value != "" {
+			params.Set(param, value)
+		}
+	}
+	connURL.RawQuery = params.Encode()
+
+	return connURL.String()
+}
+
+func (db *DB) schema() string {
+	if db.Schema == "" {
+		return DefaultSchema
+	}
+	return db.Schema
+}
+
+func (db *DB) stagingSchema() string {
+	return db.schema() + stagingSuffix
+}
+
+func (db *DB) retiredSchema() string {
+	return db.schema() + retiredSuffix
+}
+
 func (db *DB) Disconnect() {
 	if db.pool != nil {
 		db.pool.Close()
@@ -80,57 +172,121 @@ func (db *DB) Version() (string, error) {
 }
 
 func (db *DB) CreateCorreiosSchema() error {
-	_, err := db.pool.Exec(db.ctx, "CREATE SCHEMA IF NOT EXISTS correios;")
+	_, err := db.pool.Exec(db.ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s;", db.schema()))
 	if err != nil {
-		return fmt.Errorf("erro ao criar schema correios: %w", err)
+		return fmt.Errorf("erro ao criar schema %s: %w", db.schema(), err)
+	}
+
+	if err := db.createTableImportacaoRelatorio(); err != nil {
+		return fmt.Errorf("error creating importacao_relatorio: %w", err)
+	}
+
+	if err := db.createTableCepHistorico(); err != nil {
+		return fmt.Errorf("error creating cep_historico: %w", err)
 	}
 	return nil
 }
 
-func (db *DB) CreateCorreiosSql() error {
-	var wg sync.WaitGroup
-	errChan := make(chan error, immu.SIXTEEN_TASKS)
+func (db *DB) createStagingSchema() error {
+	query := fmt.Sprintf("DROP SCHEMA IF EXISTS %[1]s CASCADE; CREATE SCHEMA %[1]s;", db.stagingSchema())
+	_, err := db.pool.Exec(db.ctx, query)
+	if err != nil {
+		return fmt.Errorf("erro ao criar schema %s: %w", db.stagingSchema(), err)
+	}
+	return nil
+}
 
-	createTable := func(name string, createFn func() error) {
-		defer wg.Done()
-		if err := createFn(); err != nil {
-			log.Printf("Error creating %s: %v", name, err)
-			errChan <- fmt.Errorf("error creating %s: %w", name, err)
-		}
+func (db *DB) DiscardCorreiosSql() error {
+	query := fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE;", db.stagingSchema())
+	_, err := db.pool.Exec(db.ctx, query)
+	if err != nil {
+		return fmt.Errorf("erro ao descartar schema %s: %w", db.stagingSchema(), err)
+	}
+	return nil
+}
+
+func (db *DB) SwapCorreiosSchema() error {
+	tx, err := db.pool.Begin(db.ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar troca do schema %s: %w", db.schema(), err)
+	}
+	defer tx.Rollback(db.ctx)
+
+	statements := []string{
+		fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", db.retiredSchema()),
+		fmt.Sprintf("ALTER TABLE %s.importacao_relatorio SET SCHEMA %s", db.schema(), db.stagingSchema()),
+		fmt.Sprintf("ALTER TABLE %s.importacao_arquivo SET SCHEMA %s", db.schema(), db.stagingSchema()),
+		fmt.Sprintf("ALTER TABLE %s.cep_historico SET SCHEMA %s", db.schema(), db.stagingSchema()),
+		fmt.Sprintf("ALTER SCHEMA %s RENAME TO %s", db.schema(), db.retiredSchema()),
+		fmt.Sprintf("ALTER SCHEMA %s RENAME TO %s", db.stagingSchema(), db.schema()),
+		fmt.Sprintf("DROP SCHEMA %s CASCADE", db.retiredSchema()),
 	}
 
-	createFunction := func(createFn func() error) {
-		defer wg.Done()
-		if err := createFn(); err != nil {
-			log.Printf("Error creating: %v", err)
-			errChan <- fmt.Errorf("error creating: %w", err)
+	for _, statement := range statements {
+		if _, err := tx.Exec(db.ctx, statement); err != nil {
+			return fmt.Errorf("erro ao trocar schema %s: %w", db.schema(), err)
 		}
 	}
 
+	if err := tx.Commit(db.ctx); err != nil {
+		return fmt.Errorf("erro ao confirmar troca do schema %s: %w", db.schema(), err)
+	}
+	return nil
+}
+
+func (db *DB) CreateCorreiosSql() error {
 	if err := db.CreateCorreiosSchema(); err != nil {
 		return fmt.Errorf("error creating schema: %w", err)
 	}
 
-	wg.Add(immu.EITHTEEN_TASKS)
-
-	go createTable("ect_pais", db.createTableECTPais)
-	go createTable("log_faixa_uf", db.createTableLogFaixaUF)
-	go createTable("log_localidade", db.createTableLogLocalidade)
-	go createTable("log_var_loc", db.createTableLogVarLoc)
-	go createTable("log_faixa_localidade", db.createTableLogFaixaLocalidade)
-	go createTable("log_bairro", db.createTableLogBairro)
-	go createTable("log_var_bai", db.createTableLogVarBai)
-	go createTable("log_faixa_bairro", db.createTableLogFaixaBairro)
-	go createTable("log_cpc", db.createTableLogCPC)
-	go createTable("log_faixa_cpc", db.createTableLogFaixaCPC)
-	go createTable("log_logradouro", db.createTableLogLogradouro)
-	go createTable("log_var_log", db.createTableLogVarLog)
-	go createTable("log_num_sec", db.createTableLogNumSec)
-	go createTable("log_grande_usuario", db.createTableLogGrandeUsuario)
-	go createTable("log_unid_oper", db.createTableLogUnidOper)
-	go createTable("log_faixa_uop", db.createTableLogFaixaUOP)
-	go createTable("importacao_relatorio", db.createTableImportacaoRelatorio)
-	go createFunction(db.createConsultaCepFunction)
+	if err := db.createStagingSchema(); err != nil {
+		return fmt.Errorf("error creating staging schema: %w", err)
+	}
+
+	if err := db.createNormalizaFunction(); err != nil {
+		return fmt.Errorf("error creating normaliza function: %w", err)
+	}
+
+	tasks := []struct {
+		name   string
+		create func() error
+	}{
+		{"ect_pais", db.createTableECTPais},
+		{"log_faixa_uf", db.createTableLogFaixaUF},
+		{"log_localidade", db.createTableLogLocalidade},
+		{"log_var_loc", db.createTableLogVarLoc},
+		{"log_faixa_localidade", db.createTableLogFaixaLocalidade},
+		{"log_bairro", db.createTableLogBairro},
+		{"log_var_bai", db.createTableLogVarBai},
+		{"log_faixa_bairro", db.createTableLogFaixaBairro},
+		{"log_cpc", db.createTableLogCPC},
+		{"log_faixa_cpc", db.createTableLogFaixaCPC},
+		{"log_logradouro", db.createTableLogLogradouro},
+		{"log_var_log", db.createTableLogVarLog},
+		{"log_num_sec", db.createTableLogNumSec},
+		{"log_grande_usuario", db.createTableLogGrandeUsuario},
+		{"log_unid_oper", db.createTableLogUnidOper},
+		{"log_faixa_uop", db.createTableLogFaixaUOP},
+		{"consulta_cep", db.createConsultaCepFunction},
+		{"consulta_faixa_cep", db.createConsultaFaixaCepFunction},
+		{"busca_cep", db.createBuscaCepFunction},
+		{"consulta_cep_numero", db.createConsultaCepNumeroFunction},
+		{"consulta_cep_historico", db.createConsultaCepHistoricoFunction},
+	}
+
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(tasks))
+
+	wg.Add(len(tasks))
+	for _, task := range tasks {
+		go func() {
+			defer wg.Done()
+			if err := task.create(); err != nil {
+				log.Printf("Error creating %s: %v", task.name, err)
+				errChan <- fmt.Errorf("error creating %s: %w", task.name, err)
+			}
+		}()
+	}
 
 	wg.Wait()
 	close(errChan)
@@ -156,241 +312,1190 @@ func (db *DB) GetTotalRecords() (int, error) {
 			table_schema,
 			query_to_xml(format('select count(*) as cnt from %I.%I', table_schema, table_name), FALSE, TRUE, '') AS xml_count
 		FROM information_schema.tables
-		WHERE table_schema = 'correios'
+		WHERE table_schema = $1 AND table_name NOT IN ('cep_enderecos', 'cep_historico', 'importacao_arquivo')
 	) t;`
 
 	var total int
-	if err := db.pool.QueryRow(db.ctx, query).Scan(&total); err != nil {
+	if err := db.pool.QueryRow(db.ctx, query, db.schema()).Scan(&total); err != nil {
 		return 0, fmt.Errorf("erro ao obter total de registros: %w", err)
 	}
 	return total, nil
 }
 
 func (db *DB) GetTotalCEPs() (int, error) {
-	query := `
+	query := fmt.Sprintf(`
 	SELECT CAST(COUNT(*) AS INTEGER) as total_ceps
 	FROM (
-		SELECT cep FROM correios.log_localidade
+		SELECT cep FROM %[1]s.log_localidade
 		UNION ALL
-		SELECT cep FROM correios.log_logradouro
+		SELECT cep FROM %[1]s.log_logradouro
 		UNION ALL
-		SELECT cep FROM correios.log_grande_usuario
+		SELECT cep FROM %[1]s.log_grande_usuario
 		UNION ALL
-		SELECT cep FROM correios.log_unid_oper
+		SELECT cep FROM %[1]s.log_unid_oper
 		UNION ALL
-		SELECT cep FROM correios.log_cpc
-	) as all_ceps;`
+		SELECT cep FROM %[1]s.log_cpc
+	) as all_ceps;`, db.schema())
+
+	var total int
+	if err := db.pool.QueryRow(db.ctx, query).Scan(&total); err != nil {
+		return 0, fmt.Errorf("erro ao obter total de CEPs: %w", err)
+	}
+	return total, nil
+}
+
+func (db *DB) BulkInsertFile(ctx context.Context, fileName string, rows [][]any) error {
+	table, ok := types.TableForFile(fileName)
+	if !ok {
+		return fmt.Errorf("unknown file name: %s", fileName)
+	}
+
+	_, err := db.pool.CopyFrom(
+		ctx,
+		pgx.Identifier{db.stagingSchema(), table.Name},
+		table.ColumnNames(),
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return fmt.Errorf("error bulk inserting into %s: %w", table.Name, err)
+	}
+	return nil
+}
+
+type deltaTx struct {
+	db  *DB
+	ctx context.Context
+	tx  pgx.Tx
+}
+
+// As chaves estrangeiras (-fk) são verificadas apenas no commit, permitindo
+// remover um registro antes dos que o referenciam.
+func (db *DB) BeginDelta(ctx context.Context) (types.DeltaTx, error) {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação do delta: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, "SET CONSTRAINTS ALL DEFERRED"); err != nil {
+		tx.Rollback(db.ctx)
+		return nil, fmt.Errorf("erro ao adiar verificação de chaves estrangeiras: %w", err)
+	}
+	return &deltaTx{db: db, ctx: ctx, tx: tx}, nil
+}
+
+func (d *deltaTx) ApplyDelta(fileName string, rows [][]any) error {
+	table, ok := types.TableForFile(fileName)
+	if !ok {
+		return fmt.Errorf("unknown file name: %s", fileName)
+	}
+
+	upsert, remove := deltaQueries(d.db.schema(), table)
+	batch := &pgx.Batch{}
+
+	for i, row := range rows {
+		if len(row) != len(table.Columns)+1 {
+			return fmt.Errorf("%s linha %d: esperadas %d colunas, encontradas %d",
+				fileName, i+1, len(table.Columns)+1, len(row))
+		}
+
+		values := row[:len(table.Columns)]
+		operation, _ := row[len(table.Columns)].(string)
+
+		switch operation {
+		case immu.DELTA_INSERT, immu.DELTA_UPDATE:
+			batch.Queue(upsert, values...)
+		case immu.DELTA_DELETE:
+			batch.Queue(remove, primaryKeyValues(table, values)...)
+		default:
+			return fmt.Errorf("%s linha %d: operação desconhecida %q", fileName, i+1, operation)
+		}
+	}
+
+	if err := d.tx.SendBatch(d.ctx, batch).Close(); err != nil {
+		return fmt.Errorf("erro ao aplicar delta %s em %s: %w", fileName, table.Name, err)
+	}
+	return nil
+}
+
+func (d *deltaTx) Commit() error {
+	if err := d.tx.Commit(d.ctx); err != nil {
+		return fmt.Errorf("erro ao confirmar delta: %w", err)
+	}
+	return nil
+}
+
+// Usa o contexto da conexão, pois o do delta pode já ter sido cancelado.
+func (d *deltaTx) Rollback() error {
+	err := d.tx.Rollback(d.db.ctx)
+	if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		return fmt.Errorf("erro ao descartar delta: %w", err)
+	}
+	return nil
+}
+
+func deltaQueries(schema string, table types.Table) (string, string) {
+	identifier := pgx.Identifier{schema, table.Name}.Sanitize()
+
+	columns := make([]string, len(table.Columns))
+	placeholders := make([]string, len(table.Columns))
+	var updates []string
+	for i, column := range table.ColumnNames() {
+		columns[i] = pgx.Identifier{column}.Sanitize()
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		if !slices.Contains(table.PrimaryKey, column) {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", columns[i], columns[i]))
+		}
+	}
+
+	keys := make([]string, len(table.PrimaryKey))
+	conditions := make([]string, len(table.PrimaryKey))
+	for i, column := range table.PrimaryKey {
+		keys[i] = pgx.Identifier{column}.Sanitize()
+		conditions[i] = fmt.Sprintf("%s = $%d", keys[i], i+1)
+	}
+
+	conflict := "DO NOTHING"
+	if len(updates) > 0 {
+		conflict = "DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+
+	upsert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) %s",
+		identifier,
+		strings.Join(columns, ", "),
+		strings.Join(placeholders, ", "),
+		strings.Join(keys, ", "),
+		conflict,
+	)
+
+	remove := fmt.Sprintf("DELETE FROM %s WHERE %s", identifier, strings.Join(conditions, " AND "))
+	return upsert, remove
+}
+
+func primaryKeyValues(table types.Table, values []any) []any {
+	keys := make([]any, 0, len(table.PrimaryKey))
+	for _, column := range table.PrimaryKey {
+		keys = append(keys, values[slices.Index(table.ColumnNames(), column)])
+	}
+	return keys
+}
+
+func (db *DB) GetCep(cep string) (types.CepResponse, error) {
+	query := fmt.Sprintf("SELECT * FROM %s.consulta_cep($1);", db.schema())
+	rows, err := db.pool.Query(db.ctx, query, cep)
+	if err != nil {
+		return types.CepResponse{}, fmt.Errorf("erro ao consultar CEP: %w", err)
+	}
+
+	response, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[types.CepResponse])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return types.CepResponse{}, &types.CepNotFoundError{Cep: cep}
+	}
+
+	if err != nil {
+		return types.CepResponse{}, fmt.Errorf("erro ao consultar CEP: %w", err)
+	}
+
+	return response, nil
+}
+
+func (db *DB) GetCepFaixa(cep string) (types.CepResponse, error) {
+	query := fmt.Sprintf("SELECT * FROM %s.consulta_faixa_cep($1);", db.schema())
+	rows, err := db.pool.Query(db.ctx, query, cep)
+	if err != nil {
+		return types.CepResponse{}, fmt.Errorf("erro ao consultar faixa de CEP: %w", err)
+	}
+
+	response, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[types.CepResponse])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return types.CepResponse{}, &types.CepNotFoundError{Cep: cep}
+	}
+
+	if err != nil {
+		return types.CepResponse{}, fmt.Errorf("erro ao consultar faixa de CEP: %w", err)
+	}
+
+	return response, nil
+}
+
+func (db *DB) SearchCep(input types.BuscaCep) ([]types.CepResponse, error) {
+	query := fmt.Sprintf("SELECT * FROM %s.busca_cep($1, $2, $3, $4);", db.schema())
+	rows, err := db.pool.Query(db.ctx, query, input.UF, input.Localidade, input.Logradouro, input.Limite)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar CEP: %w", err)
+	}
+
+	response, err := pgx.CollectRows(rows, pgx.RowToStructByName[types.CepResponse])
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar CEP: %w", err)
+	}
+
+	return response, nil
+}
+
+func (db *DB) GetCepNumero(input types.BuscaNumero) (types.CepResponse, error) {
+	query := fmt.Sprintf("SELECT * FROM %s.consulta_cep_numero($1, $2, $3, $4);", db.schema())
+	rows, err := db.pool.Query(db.ctx, query, input.UF, input.Localidade, input.Logradouro, input.Numero)
+	if err != nil {
+		return types.CepResponse{}, fmt.Errorf("erro ao consultar CEP por número: %w", err)
+	}
+
+	response, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[types.CepResponse])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return types.CepResponse{}, &types.CepNotFoundError{Cep: fmt.Sprintf("%s, %d", input.Logradouro, input.Numero)}
+	}
+
+	if err != nil {
+		return types.CepResponse{}, fmt.Errorf("erro ao consultar CEP por número: %w", err)
+	}
+
+	return response, nil
+}
+
+func (db *DB) GetCepHistorico(cep string, data time.Time) (types.CepHistorico, error) {
+	query := fmt.Sprintf("SELECT * FROM %s.consulta_cep_historico($1, $2::date);", db.schema())
+	rows, err := db.pool.Query(db.ctx, query, cep, data.Format(time.DateOnly))
+	if err != nil {
+		return types.CepHistorico{}, fmt.Errorf("erro ao consultar histórico do CEP: %w", err)
+	}
+
+	response, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[types.CepHistorico])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return types.CepHistorico{}, &types.CepNotFoundError{Cep: cep}
+	}
+
+	if err != nil {
+		return types.CepHistorico{}, fmt.Errorf("erro ao consultar histórico do CEP: %w", err)
+	}
+
+	response.ValidoDe = response.ValidoDe.UTC()
+	if response.ValidoAte != nil {
+		validoAte := response.ValidoAte.UTC()
+		response.ValidoAte = &validoAte
+	}
+	return response, nil
+}
+
+func (db *DB) StreamTable(ctx context.Context, table types.Table, handle func(row []any) error) error {
+	columns := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		columns[i] = column.Name + "::text"
+	}
+
+	// O diff intercala as linhas pela chave: texto em ordem de bytes, sem
+	// depender da collation do banco.
+	order := make([]string, len(table.PrimaryKey))
+	for i, name := range table.PrimaryKey {
+		order[i] = name
+		if column, ok := table.Column(name); ok && !column.Numeric {
+			order[i] = name + ` COLLATE "C"`
+		}
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s.%s ORDER BY %s",
+		strings.Join(columns, ", "),
+		db.schema(),
+		table.Name,
+		strings.Join(order, ", "),
+	)
+
+	rows, err := db.pool.Query(ctx, query)
+	if err != nil {
+		return fmt.Errorf("erro ao ler tabela %s: %w", table.Name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return fmt.Errorf("erro ao ler tabela %s: %w", table.Name, err)
+		}
+
+		if err := handle(values); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("erro ao ler tabela %s: %w", table.Name, err)
+	}
+	return nil
+}
+
+func (db *DB) InsertImportacaoRelatorio(input types.ImportacaoRelatorio) (int, error) {
+	query := fmt.Sprintf(`
+	INSERT INTO %[1]s.importacao_relatorio (
+		tipo,
+		situacao,
+		total_registros,
+		total_ceps,
+		versao_base,
+		duracao,
+		observacoes
+	) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id
+	`, db.schema())
+
+	var id int
+	err := db.pool.QueryRow(db.ctx, query,
+		input.Tipo,
+		input.Situacao,
+		input.TotalRegistros,
+		input.TotalCeps,
+		input.VersaoEDNE,
+		input.Duracao,
+		input.Observacoes,
+	).Scan(&id)
+
+	if err != nil {
+		return 0, fmt.Errorf("erro ao inserir relatório de importação: %w", err)
+	}
+
+	return id, nil
+}
+
+func (db *DB) InsertImportacaoArquivos(importacaoID int, arquivos []types.ImportacaoArquivo) error {
+	query := fmt.Sprintf(`
+	INSERT INTO %[1]s.importacao_arquivo (
+		importacao_id,
+		arquivo,
+		linhas,
+		registros,
+		bytes,
+		checksum,
+		duracao,
+		erro
+	) VALUES ($1, $2, $3, $4, $5, nullif($6, ''), $7, nullif($8, ''))
+	`, db.schema())
+
+	if len(arquivos) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, arquivo := range arquivos {
+		batch.Queue(query,
+			importacaoID,
+			arquivo.Arquivo,
+			arquivo.Linhas,
+			arquivo.Registros,
+			arquivo.Bytes,
+			arquivo.Checksum,
+			arquivo.Duracao,
+			arquivo.Erro,
+		)
+	}
+
+	if err := db.pool.SendBatch(db.ctx, batch).Close(); err != nil {
+		return fmt.Errorf("erro ao inserir relatório dos arquivos: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) ListImportacaoArquivos(importacaoID int) ([]types.ImportacaoArquivo, error) {
+	query := fmt.Sprintf(`
+	SELECT arquivo, linhas, registros, bytes, coalesce(checksum, ''),
+		extract(epoch FROM duracao)::float8, coalesce(erro, '')
+	FROM %[1]s.importacao_arquivo
+	WHERE importacao_id = $1
+	ORDER BY arquivo`, db.schema())
+
+	rows, err := db.pool.Query(db.ctx, query, importacaoID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar relatório dos arquivos: %w", err)
+	}
+	defer rows.Close()
+
+	var arquivos []types.ImportacaoArquivo
+	for rows.Next() {
+		var arquivo types.ImportacaoArquivo
+		var seconds float64
+		err := rows.Scan(
+			&arquivo.Arquivo,
+			&arquivo.Linhas,
+			&arquivo.Registros,
+			&arquivo.Bytes,
+			&arquivo.Checksum,
+			&seconds,
+			&arquivo.Erro,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar relatório dos arquivos: %w", err)
+		}
+
+		arquivo.Duracao = time.Duration(seconds * float64(time.Second)).Round(time.Millisecond)
+		arquivos = append(arquivos, arquivo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao listar relatório dos arquivos: %w", err)
+	}
+	return arquivos, nil
+}
+
+func (db *DB) ListImportacaoRelatorio(limite int) ([]types.ImportacaoRelatorio, error) {
+	query := fmt.Sprintf(`
+	SELECT id, executado_em, tipo, situacao, total_registros, total_ceps, versao_base,
+		extract(epoch FROM duracao)::float8, coalesce(observacoes, '')
+	FROM %[1]s.importacao_relatorio
+	ORDER BY id DESC
+	LIMIT $1`, db.schema())
+
+	rows, err := db.pool.Query(db.ctx, query, limite)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar relatórios de importação: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []types.ImportacaoRelatorio
+	for rows.Next() {
+		var report types.ImportacaoRelatorio
+		var seconds float64
+		err := rows.Scan(
+			&report.ID,
+			&report.ExecutadoEm,
+			&report.Tipo,
+			&report.Situacao,
+			&report.TotalRegistros,
+			&report.TotalCeps,
+			&report.VersaoEDNE,
+			&seconds,
+			&report.Observacoes,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar relatórios de importação: %w", err)
+		}
+
+		report.Duracao = time.Duration(seconds * float64(time.Second)).Round(time.Millisecond)
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao listar relatórios de importação: %w", err)
+	}
+	return reports, nil
+}
+
+// Copia para o staging as tabelas que não fazem parte da carga atual, para
+// que a troca de schema não as deixe vazias.
+func (db *DB) CopyCorreiosTables(tables []types.Table) error {
+	for _, table := range tables {
+		var exists bool
+		err := db.pool.QueryRow(db.ctx, "SELECT to_regclass($1) IS NOT NULL", db.schema()+"."+table.Name).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("erro ao verificar tabela %s: %w", table.Name, err)
+		}
+
+		if !exists {
+			continue
+		}
+
+		query := fmt.Sprintf("INSERT INTO %[1]s.%[3]s SELECT * FROM %[2]s.%[3]s",
+			db.stagingSchema(), db.schema(), table.Name)
+		if _, err := db.pool.Exec(db.ctx, query); err != nil {
+			return fmt.Errorf("erro ao copiar tabela %s: %w", table.Name, err)
+		}
+	}
+	return nil
+}
+
+func (db *DB) ExistsImportacaoVersao(tipo string, versao string) (bool, error) {
+	query := fmt.Sprintf(`
+	SELECT EXISTS (
+		SELECT 1 FROM %[1]s.importacao_relatorio
+		WHERE tipo = $1 AND versao_base = $2 AND situacao IN ('concluida', 'pendente')
+	)`, db.schema())
+
+	var exists bool
+	if err := db.pool.QueryRow(db.ctx, query, tipo, versao).Scan(&exists); err != nil {
+		return false, fmt.Errorf("erro ao verificar versão importada: %w", err)
+	}
+	return exists, nil
+}
+
+func (db *DB) CheckIntegrity() ([]types.IntegrityIssue, error) {
+	var issues []types.IntegrityIssue
+	for _, table := range types.CorreiosTables {
+		for _, reference := range table.References {
+			query := fmt.Sprintf(`
+			SELECT count(*) FROM %[1]s.%[2]s t
+			WHERE t.%[3]s IS NOT NULL AND NOT EXISTS (
+				SELECT 1 FROM %[1]s.%[4]s r WHERE r.%[5]s = t.%[3]s
+			)`, db.stagingSchema(), table.Name, reference.Column, reference.Table, reference.TargetColumn)
+
+			var orphans int
+			if err := db.pool.QueryRow(db.ctx, query).Scan(&orphans); err != nil {
+				return nil, fmt.Errorf("erro ao verificar %s.%s: %w", table.Name, reference.Column, err)
+			}
+
+			issues = append(issues, types.IntegrityIssue{Table: table.Name, Reference: reference, Orphans: orphans})
+		}
+	}
+	return issues, nil
+}
+
+func (db *DB) CreateForeignKeys() error {
+	for _, table := range types.CorreiosTables {
+		for _, reference := range table.References {
+			query := fmt.Sprintf(
+				"ALTER TABLE %[1]s.%[2]s ADD CONSTRAINT fk_%[2]s_%[3]s FOREIGN KEY (%[3]s) REFERENCES %[1]s.%[4]s (%[5]s) DEFERRABLE",
+				db.stagingSchema(), table.Name, reference.Column, reference.Table, reference.TargetColumn,
+			)
+			if _, err := db.pool.Exec(db.ctx, query); err != nil {
+				return fmt.Errorf("erro ao criar chave estrangeira %s.%s: %w", table.Name, reference.Column, err)
+			}
+		}
+	}
+	return nil
+}
+
+type indexDefinition struct {
+	table      string
+	name       string
+	definition string
+}
+
+// Os índices são criados após a carga, em paralelo (limitado pelo pool de
+// conexões); CONCURRENTLY evita bloquear escritas, ao custo de mais tempo.
+func (db *DB) CreateIndexes(ctx context.Context, concurrently bool) ([]types.IndexBuild, error) {
+	indexes := []indexDefinition{
+		{"log_logradouro", "log_logradouro_log_no_trgm_idx", "USING gin (%[1]s.normaliza(log_no) gin_trgm_ops)"},
+		{"log_logradouro", "log_logradouro_tlo_tx_log_no_trgm_idx", "USING gin (%[1]s.normaliza(tlo_tx || ' ' || log_no) gin_trgm_ops)"},
+		{"log_var_log", "log_var_log_vlo_tx_trgm_idx", "USING gin (%[1]s.normaliza(vlo_tx) gin_trgm_ops)"},
+	}
+
+	for _, table := range types.CorreiosTables {
+		for _, column := range table.IndexedColumns() {
+			indexes = append(indexes, indexDefinition{table.Name, fmt.Sprintf("%s_%s_idx", table.Name, column), "(" + column + ")"})
+		}
+	}
+
+	option := ""
+	if concurrently {
+		option = "CONCURRENTLY "
+	}
+
+	var wg sync.WaitGroup
+	builds := make([]types.IndexBuild, len(indexes))
+	errChan := make(chan error, len(indexes))
+
+	wg.Add(len(indexes))
+	for i, index := range indexes {
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			query := fmt.Sprintf("CREATE INDEX %s%s ON %s.%s %s",
+				option, index.name, db.stagingSchema(), index.table, fmt.Sprintf(index.definition, db.stagingSchema()))
+
+			if _, err := db.pool.Exec(ctx, query); err != nil {
+				errChan <- fmt.Errorf("erro ao criar índice %s: %w", index.name, err)
+				return
+			}
+
+			builds[i] = types.IndexBuild{Table: index.table, Index: index.name, Duracao: time.Since(start).Round(time.Millisecond)}
+		}()
+	}
+
+	wg.Wait()
+	close(errChan)
+
+	var errMsgs []string
+	for err := range errChan {
+		errMsgs = append(errMsgs, err.Error())
+	}
+
+	if len(errMsgs) > 0 {
+		return nil, fmt.Errorf("erro ao criar índices: %s", strings.Join(errMsgs, "; "))
+	}
+	return builds, nil
+}
+
+func (db *DB) createNormalizaFunction() error {
+	query := fmt.Sprintf(`
+    CREATE EXTENSION IF NOT EXISTS unaccent WITH SCHEMA public;
+    CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA public;
+    CREATE OR REPLACE FUNCTION %s.normaliza(t text)
+     RETURNS text
+     LANGUAGE sql
+     IMMUTABLE PARALLEL SAFE STRICT
+    AS $function$
+    SELECT lower(public.unaccent('public.unaccent'::regdictionary, t));
+    $function$
+    ;`, db.stagingSchema())
+
+	_, err := db.pool.Exec(db.ctx, query)
+	if err != nil {
+		return fmt.Errorf("erro ao criar função normaliza: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) createBuscaCepFunction() error {
+	query := fmt.Sprintf(`
+    CREATE OR REPLACE FUNCTION %[1]s.busca_cep(uf_busca text, localidade_busca text, logradouro_busca text, limite integer DEFAULT 20)
+     RETURNS TABLE(uf text, localidade text, cep text, ibge text, bairro text, complemento text, logradouro text, nome text, fonte text)
+     LANGUAGE plpgsql
+    AS $function$
+    DECLARE
+        termo text := %[2]s.normaliza(logradouro_busca);
+        cidade text := coalesce(%[2]s.normaliza(localidade_busca), '');
+        sigla text := upper(coalesce(uf_busca, ''));
+    BEGIN
+        RETURN QUERY
+        WITH candidatos AS (
+            SELECT
+                llog.log_nu,
+                %[2]s.normaliza(llog.log_no) AS texto
+            FROM
+                %[2]s.log_logradouro llog
+            WHERE (sigla = '' OR llog.ufe_sg = sigla)
+                AND (%[2]s.normaliza(llog.log_no) LIKE termo || '%%'
+                    OR %[2]s.normaliza(llog.log_no) %% termo)
+            UNION ALL
+            SELECT
+                llog.log_nu,
+                %[2]s.normaliza(llog.tlo_tx || ' ' || llog.log_no) AS texto
+            FROM
+                %[2]s.log_logradouro llog
+            WHERE (sigla = '' OR llog.ufe_sg = sigla)
+                AND %[2]s.normaliza(llog.tlo_tx || ' ' || llog.log_no) LIKE termo || '%%'
+            UNION ALL
+            SELECT
+                lvl.log_nu,
+                %[2]s.normaliza(lvl.vlo_tx) AS texto
+            FROM
+                %[2]s.log_var_log lvl
+            WHERE
+                %[2]s.normaliza(lvl.vlo_tx) LIKE termo || '%%'
+                OR %[2]s.normaliza(lvl.vlo_tx) %% termo
+        ),
+        ranqueados AS (
+            SELECT
+                c.log_nu,
+                bool_or(c.texto LIKE termo || '%%') AS prefixo,
+                max(similarity(c.texto, termo)) AS similaridade
+            FROM
+                candidatos c
+            GROUP BY
+                c.log_nu
+        )
+        SELECT
+            llog.ufe_sg::text AS uf,
+            (
+                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
+                    ll.loc_no
+                ELSE
+                    ll2.loc_no
+                END)::text AS localidade,
+            llog.cep::text,
+            (
+                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
+                    ll.mun_nu
+                ELSE
+                    ll2.mun_nu
+                END)::text AS ibge,
+            lb.bai_no::text AS bairro,
+            llog.log_complemento::text AS complemento,
+            (llog.tlo_tx || ' ' || llog.log_no)::text AS logradouro,
+            NULL::text AS nome,
+            'log_logradouro'::text AS fonte
+        FROM
+            ranqueados r
+            JOIN %[2]s.log_logradouro llog ON llog.log_nu = r.log_nu
+            JOIN %[2]s.log_localidade ll ON ll.loc_nu = llog.loc_nu
+            LEFT JOIN %[2]s.log_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub
+                AND ll.loc_in_tipo_loc <> 'M'
+            LEFT JOIN %[2]s.log_bairro lb ON lb.bai_nu = llog.bai_nu_ini
+        WHERE (sigla = '' OR llog.ufe_sg = sigla)
+            AND (cidade = ''
+                OR %[2]s.normaliza(ll.loc_no) = cidade
+                OR %[2]s.normaliza(ll2.loc_no) = cidade)
+        ORDER BY
+            r.prefixo DESC,
+            r.similaridade DESC,
+            7
+        LIMIT limite;
+    END;
+    $function$
+    ;`, db.stagingSchema(), db.schema())
+
+	_, err := db.pool.Exec(db.ctx, query)
+	if err != nil {
+		return fmt.Errorf("erro ao criar função busca_cep: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) createConsultaCepNumeroFunction() error {
+	query := fmt.Sprintf(`
+    CREATE OR REPLACE FUNCTION %[1]s.consulta_cep_numero(uf_busca text, localidade_busca text, logradouro_busca text, numero integer)
+     RETURNS TABLE(uf text, localidade text, cep text, ibge text, bairro text, complemento text, logradouro text, nome text, fonte text)
+     LANGUAGE plpgsql
+    AS $function$
+    DECLARE
+        termo text := %[2]s.normaliza(logradouro_busca);
+        cidade text := coalesce(%[2]s.normaliza(localidade_busca), '');
+        sigla text := upper(coalesce(uf_busca, ''));
+    BEGIN
+        RETURN QUERY
+        SELECT
+            llog.ufe_sg::text AS uf,
+            (
+                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
+                    ll.loc_no
+                ELSE
+                    ll2.loc_no
+                END)::text AS localidade,
+            llog.cep::text,
+            (
+                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
+                    ll.mun_nu
+                ELSE
+                    ll2.mun_nu
+                END)::text AS ibge,
+            lb.bai_no::text AS bairro,
+            llog.log_complemento::text AS complemento,
+            (llog.tlo_tx || ' ' || llog.log_no)::text AS logradouro,
+            NULL::text AS nome,
+            'log_logradouro'::text AS fonte
+        FROM
+            %[2]s.log_logradouro llog
+            JOIN %[2]s.log_localidade ll ON ll.loc_nu = llog.loc_nu
+            LEFT JOIN %[2]s.log_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub
+                AND ll.loc_in_tipo_loc <> 'M'
+            LEFT JOIN %[2]s.log_bairro lb ON lb.bai_nu = llog.bai_nu_ini
+            LEFT JOIN LATERAL (
+                SELECT
+                    (CASE WHEN lns.sec_nu_ini ~ '^[0-9]+$' THEN lns.sec_nu_ini::bigint END) AS inicio,
+                    (CASE WHEN lns.sec_nu_fim ~ '^[0-9]+$' THEN lns.sec_nu_fim::bigint END) AS fim,
+                    lns.sec_in_lado AS lado
+                FROM
+                    %[2]s.log_num_sec lns
+                WHERE
+                    lns.log_nu = llog.log_nu) sec ON TRUE
+        WHERE (sigla = '' OR llog.ufe_sg = sigla)
+            AND (cidade = ''
+                OR %[2]s.normaliza(ll.loc_no) = cidade
+                OR %[2]s.normaliza(ll2.loc_no) = cidade)
+            AND (%[2]s.normaliza(llog.log_no) = termo
+                OR %[2]s.normaliza(llog.tlo_tx || ' ' || llog.log_no) = termo)
+            AND (sec.lado IS NULL
+                OR (numero BETWEEN sec.inicio AND sec.fim
+                    AND (sec.lado NOT IN ('P', 'I')
+                        OR (sec.lado = 'P' AND numero %% 2 = 0)
+                        OR (sec.lado = 'I' AND numero %% 2 = 1))))
+        ORDER BY
+            sec.lado IS NULL,
+            sec.fim - sec.inicio
+        LIMIT 1;
+    END;
+    $function$
+    ;`, db.stagingSchema(), db.schema())
+
+	_, err := db.pool.Exec(db.ctx, query)
+	if err != nil {
+		return fmt.Errorf("erro ao criar função consulta_cep_numero: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) createConsultaCepFunction() error {
+	query := fmt.Sprintf(`
+    CREATE OR REPLACE FUNCTION %[1]s.consulta_cep(c text)
+     RETURNS TABLE(uf text, localidade text, cep text, ibge text, bairro text, complemento text, logradouro text, nome text, fonte text)
+     LANGUAGE plpgsql
+    AS $function$
+    BEGIN
+        RETURN QUERY
+        SELECT
+            ce.uf,
+            ce.localidade,
+            ce.cep,
+            ce.ibge,
+            ce.bairro,
+            ce.complemento,
+            ce.logradouro,
+            ce.nome,
+            ce.fonte
+        FROM
+            %[2]s.cep_enderecos ce
+        WHERE
+            ce.cep = c;
+    END;
+    $function$
+    ;`, db.stagingSchema(), db.schema())
+
+	_, err := db.pool.Exec(db.ctx, query)
+	if err != nil {
+		return fmt.Errorf("erro ao criar função consulta_cep: %w", err)
+	}
+	return nil
+}
+
+// Uma linha por CEP: quando o mesmo CEP aparece em mais de uma tabela de
+// origem, prevalece a de menor prioridade.
+const cepEnderecosQuery = `
+    SELECT DISTINCT ON (e.cep)
+        e.cep,
+        e.uf,
+        e.localidade,
+        e.ibge,
+        e.bairro,
+        e.complemento,
+        e.logradouro,
+        e.nome,
+        e.fonte
+    FROM (
+        SELECT
+            llog.cep::text AS cep,
+            llog.ufe_sg::text AS uf,
+            (
+                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
+                    ll.loc_no
+                ELSE
+                    ll2.loc_no
+                END)::text AS localidade,
+            (
+                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
+                    ll.mun_nu
+                ELSE
+                    ll2.mun_nu
+                END)::text AS ibge,
+            lb.bai_no::text AS bairro,
+            llog.log_complemento::text AS complemento,
+            (llog.tlo_tx || ' ' || llog.log_no)::text AS logradouro,
+            NULL::text AS nome,
+            'log_logradouro'::text AS fonte,
+            1 AS prioridade
+        FROM
+            %[1]s.log_logradouro llog
+            JOIN %[1]s.log_localidade ll ON ll.loc_nu = llog.loc_nu
+            LEFT JOIN %[1]s.log_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub
+                AND ll.loc_in_tipo_loc <> 'M'
+            LEFT JOIN %[1]s.log_bairro lb ON lb.bai_nu = llog.bai_nu_ini
+        UNION ALL
+        SELECT
+            lgu.cep::text AS cep,
+            lgu.ufe_sg::text AS uf,
+            (
+                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
+                    ll.loc_no_abrev
+                ELSE
+                    ll2.loc_no
+                END)::text AS localidade,
+            (
+                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
+                    ll.mun_nu
+                ELSE
+                    ll2.mun_nu
+                END)::text AS ibge,
+            lb.bai_no::text AS bairro,
+            NULL::text AS complemento,
+            lgu.gru_endereco::text AS logradouro,
+            lgu.gru_no::text AS nome,
+            'log_grande_usuario'::text AS fonte,
+            2 AS prioridade
+        FROM
+            %[1]s.log_grande_usuario lgu
+            JOIN %[1]s.log_localidade ll ON ll.loc_nu = lgu.loc_nu
+            LEFT JOIN %[1]s.log_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub
+                AND ll.loc_in_tipo_loc <> 'M'
+            LEFT JOIN %[1]s.log_bairro lb ON lb.bai_nu = lgu.bai_nu
+        UNION ALL
+        SELECT
+            luo.cep::text AS cep,
+            luo.ufe_sg::text AS uf,
+            (
+                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
+                    ll.loc_no
+                ELSE
+                    ll2.loc_no
+                END)::text AS localidade,
+            (
+                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
+                    ll.mun_nu
+                ELSE
+                    ll2.mun_nu
+                END)::text AS ibge,
+            lb.bai_no::text AS bairro,
+            NULL::text AS complemento,
+            luo.uop_endereco::text AS logradouro,
+            luo.uop_no::text AS nome,
+            'log_unid_oper'::text AS fonte,
+            3 AS prioridade
+        FROM
+            %[1]s.log_unid_oper luo
+            JOIN %[1]s.log_localidade ll ON ll.loc_nu = luo.loc_nu
+            LEFT JOIN %[1]s.log_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub
+                AND ll.loc_in_tipo_loc <> 'M'
+            LEFT JOIN %[1]s.log_bairro lb ON lb.bai_nu = luo.bai_nu
+        UNION ALL
+        SELECT
+            lcpc.cep::text AS cep,
+            lcpc.ufe_sg::text AS uf,
+            (
+                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
+                    ll.loc_no
+                ELSE
+                    ll2.loc_no
+                END)::text AS localidade,
+            (
+                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
+                    ll.mun_nu
+                ELSE
+                    ll2.mun_nu
+                END)::text AS ibge,
+            NULL::text AS bairro,
+            NULL::text AS complemento,
+            lcpc.cpc_endereco::text AS logradouro,
+            lcpc.cpc_no::text AS nome,
+            'log_cpc'::text AS fonte,
+            4 AS prioridade
+        FROM
+            %[1]s.log_cpc lcpc
+            JOIN %[1]s.log_localidade ll ON ll.loc_nu = lcpc.loc_nu
+            LEFT JOIN %[1]s.log_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub
+                AND ll.loc_in_tipo_loc <> 'M'
+        UNION ALL
+        SELECT
+            ll.cep::text AS cep,
+            ll.ufe_sg::text AS uf,
+            (
+                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
+                    ll.loc_no
+                ELSE
+                    ll2.loc_no
+                END)::text AS localidade,
+            (
+                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
+                    ll.mun_nu
+                ELSE
+                    ll2.mun_nu
+                END)::text AS ibge,
+            NULL::text AS bairro,
+            NULL::text AS complemento,
+            NULL::text AS logradouro,
+            NULL::text AS nome,
+            'log_localidade'::text AS fonte,
+            5 AS prioridade
+        FROM
+            %[1]s.log_localidade ll
+            LEFT JOIN %[1]s.log_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub
+                AND ll.loc_in_tipo_loc <> 'M'
+        WHERE
+            ll.cep IS NOT NULL) e
+    ORDER BY
+        e.cep,
+        e.prioridade`
+
+func (db *DB) CreateCepEnderecos(ctx context.Context) error {
+	return db.buildCepEnderecos(ctx, db.stagingSchema())
+}
+
+func (db *DB) RefreshCepEnderecos(ctx context.Context) error {
+	return db.buildCepEnderecos(ctx, db.schema())
+}
 
-	var total int
-	if err := db.pool.QueryRow(db.ctx, query).Scan(&total); err != nil {
-		return 0, fmt.Errorf("erro ao obter total de CEPs: %w", err)
+// A tabela é montada ao lado da atual e trocada na mesma transação, para
+// que as consultas não fiquem sem resultado durante a atualização.
+func (db *DB) buildCepEnderecos(ctx context.Context, schema string) error {
+	tx, err := db.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar criação de %s.cep_enderecos: %w", schema, err)
+	}
+	defer tx.Rollback(db.ctx)
+
+	statements := []string{
+		"DROP TABLE IF EXISTS %[1]s.cep_enderecos_novo",
+		"CREATE TABLE %[1]s.cep_enderecos_novo AS " + cepEnderecosQuery,
+		"ALTER TABLE %[1]s.cep_enderecos_novo ADD CONSTRAINT cep_enderecos_novo_pkey PRIMARY KEY (cep)",
+		"DROP TABLE IF EXISTS %[1]s.cep_enderecos",
+		"ALTER TABLE %[1]s.cep_enderecos_novo RENAME TO cep_enderecos",
+		"ALTER INDEX %[1]s.cep_enderecos_novo_pkey RENAME TO cep_enderecos_pkey",
+		"COMMENT ON TABLE %[1]s.cep_enderecos IS 'endereços desnormalizados, uma linha por CEP'",
 	}
-	return total, nil
-}
 
-func (db *DB) BulkInsertFile(fileName string, rows [][]any) error {
-	if strings.HasPrefix(fileName, "LOG_LOGRADOURO_") && strings.HasSuffix(fileName, ".TXT") {
-		return db.bulkInsertLogLogradouro(rows)
-	}
-
-	switch fileName {
-	case "ECT_PAIS.TXT":
-		return db.bulkInsertECTPais(rows)
-	case "LOG_FAIXA_UF.TXT":
-		return db.bulkInsertLogFaixaUF(rows)
-	case "LOG_LOCALIDADE.TXT":
-		return db.bulkInsertLogLocalidade(rows)
-	case "LOG_VAR_LOC.TXT":
-		return db.bulkInsertLogVarLoc(rows)
-	case "LOG_FAIXA_LOCALIDADE.TXT":
-		return db.bulkInsertLogFaixaLocalidade(rows)
-	case "LOG_BAIRRO.TXT":
-		return db.bulkInsertLogBairro(rows)
-	case "LOG_VAR_BAI.TXT":
-		return db.bulkInsertLogVarBai(rows)
-	case "LOG_FAIXA_BAIRRO.TXT":
-		return db.bulkInsertLogFaixaBairro(rows)
-	case "LOG_CPC.TXT":
-		return db.bulkInsertLogCPC(rows)
-	case "LOG_FAIXA_CPC.TXT":
-		return db.bulkInsertLogFaixaCPC(rows)
-	case "LOG_VAR_LOG.TXT":
-		return db.bulkInsertLogVarLog(rows)
-	case "LOG_NUM_SEC.TXT":
-		return db.bulkInsertLogNumSec(rows)
-	case "LOG_GRANDE_USUARIO.TXT":
-		return db.bulkInsertLogGrandeUsuario(rows)
-	case "LOG_UNID_OPER.TXT":
-		return db.bulkInsertLogUnidOper(rows)
-	case "LOG_FAIXA_UOP.TXT":
-		return db.bulkInsertLogFaixaUOP(rows)
-	default:
-		return fmt.Errorf("unknown file name: %s", fileName)
+	for _, statement := range statements {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(statement, schema)); err != nil {
+			return fmt.Errorf("erro ao criar %s.cep_enderecos: %w", schema, err)
+		}
 	}
-}
 
-func (db *DB) GetCep(cep string) (types.CepResponse, error) {
-	query := "SELECT * FROM correios.consulta_cep($1);"
-	var response types.CepResponse
-	if err := db.pool.QueryRow(db.ctx, query, cep).Scan(&response); err != nil {
-		return types.CepResponse{}, fmt.Errorf("erro ao consultar CEP: %w", err)
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("erro ao confirmar criação de %s.cep_enderecos: %w", schema, err)
 	}
-	return response, nil
+	return nil
 }
 
-func (db *DB) InsertImportacaoRelatorio(input types.ImportacaoRelatorio) error {
-	query := `
-	INSERT INTO correios.importacao_relatorio (
-		total_registros,
-		total_ceps,
-		versao_base,
-		duracao,
-		observacoes
-	) VALUES ($1, $2, $3, $4, $5)
-	`
-
-	_, err := db.pool.Exec(db.ctx, query,
-		input.TotalRegistros,
-		input.TotalCeps,
-		input.VersaoEDNE,
-		input.Duracao,
-		input.Observacoes,
-	)
+// Histórico tipo 2: encerra a versão vigente dos CEPs removidos ou alterados
+// e abre uma nova para os CEPs sem versão vigente, na mesma transação.
+func (db *DB) UpdateCepHistorico(versao string) (int, int, error) {
+	tx, err := db.pool.Begin(db.ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("erro ao iniciar atualização de cep_historico: %w", err)
+	}
+	defer tx.Rollback(db.ctx)
+
+	encerra := fmt.Sprintf(`
+	UPDATE %[1]s.cep_historico h
+	SET valido_ate = now(), versao_ate = $1
+	WHERE h.valido_ate IS NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM %[1]s.cep_enderecos e
+			WHERE e.cep = h.cep
+				AND e.uf IS NOT DISTINCT FROM h.uf
+				AND e.localidade IS NOT DISTINCT FROM h.localidade
+				AND e.ibge IS NOT DISTINCT FROM h.ibge
+				AND e.bairro IS NOT DISTINCT FROM h.bairro
+				AND e.complemento IS NOT DISTINCT FROM h.complemento
+				AND e.logradouro IS NOT DISTINCT FROM h.logradouro
+				AND e.nome IS NOT DISTINCT FROM h.nome
+				AND e.fonte IS NOT DISTINCT FROM h.fonte)`, db.schema())
+
+	abre := fmt.Sprintf(`
+	INSERT INTO %[1]s.cep_historico (cep, uf, localidade, ibge, bairro, complemento, logradouro, nome, fonte, versao_de, valido_de)
+	SELECT e.cep, e.uf, e.localidade, e.ibge, e.bairro, e.complemento, e.logradouro, e.nome, e.fonte, $1, now()
+	FROM %[1]s.cep_enderecos e
+	WHERE NOT EXISTS (
+		SELECT 1 FROM %[1]s.cep_historico h
+		WHERE h.cep = e.cep AND h.valido_ate IS NULL)`, db.schema())
+
+	encerrados, err := tx.Exec(db.ctx, encerra, versao)
+	if err != nil {
+		return 0, 0, fmt.Errorf("erro ao encerrar versões de cep_historico: %w", err)
+	}
 
+	abertos, err := tx.Exec(db.ctx, abre, versao)
 	if err != nil {
-		return fmt.Errorf("erro ao inserir relatório de importação: %w", err)
+		return 0, 0, fmt.Errorf("erro ao abrir versões de cep_historico: %w", err)
 	}
 
-	return nil
+	if err := tx.Commit(db.ctx); err != nil {
+		return 0, 0, fmt.Errorf("erro ao confirmar atualização de cep_historico: %w", err)
+	}
+	return int(abertos.RowsAffected()), int(encerrados.RowsAffected()), nil
 }
 
-func (db *DB) createConsultaCepFunction() error {
-	query := `
-    CREATE OR REPLACE FUNCTION correios.consulta_cep(c text)
-     RETURNS TABLE(uf text, localidade text, cep text, ibge text, bairro text, complemento text, logradouro text)
+func (db *DB) createConsultaCepHistoricoFunction() error {
+	query := fmt.Sprintf(`
+    CREATE OR REPLACE FUNCTION %[1]s.consulta_cep_historico(c text, data date)
+     RETURNS TABLE(uf text, localidade text, cep text, ibge text, bairro text, complemento text, logradouro text, nome text, fonte text,
+        versao_edne text, valido_de timestamptz, valido_ate timestamptz)
      LANGUAGE plpgsql
     AS $function$
+    DECLARE
+        -- Fim do dia informado em UTC, como no SQLite, independente do TimeZone da sessão.
+        fim_do_dia timestamptz := (data + 1)::timestamp AT TIME ZONE 'UTC';
     BEGIN
         RETURN QUERY
         SELECT
-            ll.ufe_sg::text AS uf,
-            (
-                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
-                    ll.loc_no
-                ELSE
-                    ll2.loc_no
-                END)::text AS localidade,
-            ll.cep::text,
-            (
-                CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
-                    ll.mun_nu
-                ELSE
-                    ll2.mun_nu
-                END)::text AS ibge,
-            NULL::text AS bairro,
-            NULL::text AS complemento,
-            NULL::text AS logradouro
+            h.uf,
+            h.localidade,
+            h.cep,
+            h.ibge,
+            h.bairro,
+            h.complemento,
+            h.logradouro,
+            h.nome,
+            h.fonte,
+            h.versao_de::text AS versao_edne,
+            h.valido_de,
+            h.valido_ate
         FROM
-            correios.log_localidade ll
-        LEFT JOIN correios.log_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub
-            AND ll.loc_in_tipo_loc <> 'M'
-    WHERE
-        ll.cep = c
-    UNION
+            %[2]s.cep_historico h
+        WHERE
+            h.cep = c
+            AND h.valido_de < fim_do_dia
+            AND (h.valido_ate IS NULL
+                OR h.valido_ate >= fim_do_dia)
+        ORDER BY
+            h.valido_de DESC
+        LIMIT 1;
+    END;
+    $function$
+    ;`, db.stagingSchema(), db.schema())
+
+	_, err := db.pool.Exec(db.ctx, query)
+	if err != nil {
+		return fmt.Errorf("erro ao criar função consulta_cep_historico: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) createConsultaFaixaCepFunction() error {
+	query := fmt.Sprintf(`
+    CREATE OR REPLACE FUNCTION %[1]s.consulta_faixa_cep(c text)
+     RETURNS TABLE(uf text, localidade text, cep text, ibge text, bairro text, complemento text, logradouro text, nome text, fonte text)
+     LANGUAGE plpgsql
+    AS $function$
+    BEGIN
+        RETURN QUERY
     SELECT
-        llog.ufe_sg::text AS uf,
+        fu.ufe_sg::text AS uf,
         (
             CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
                 ll.loc_no
             ELSE
                 ll2.loc_no
             END)::text AS localidade,
-        llog.cep::text,
+        c AS cep,
         (
             CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
                 ll.mun_nu
             ELSE
                 ll2.mun_nu
             END)::text AS ibge,
-        lb.bai_no::text AS bairro,
-        llog.log_complemento::text AS complemento,
-        (llog.tlo_tx || ' ' || llog.log_no)::text AS logradouro
-    FROM
-        correios.log_logradouro llog
-        JOIN correios.log_localidade ll ON ll.loc_nu = llog.loc_nu
-        LEFT JOIN correios.log_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub
-            AND ll.loc_in_tipo_loc <> 'M'
-        LEFT JOIN correios.log_bairro lb ON lb.bai_nu = llog.bai_nu_ini
-    WHERE
-        llog.cep = c
-    UNION
-    SELECT
-        lgu.ufe_sg::text AS uf,
-        (
-            CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
-                ll.loc_no_abrev
-            ELSE
-                ll2.loc_no
-            END)::text AS localidade,
-        lgu.cep::text,
         (
-            CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
-                ll.mun_nu
-            ELSE
-                ll2.mun_nu
-            END)::text AS ibge,
-        lb.bai_no::text AS bairro,
+            SELECT
+                lb.bai_no
+            FROM
+                %[2]s.log_faixa_bairro fb
+                JOIN %[2]s.log_bairro lb ON lb.bai_nu = fb.bai_nu
+            WHERE
+                lb.loc_nu = ll.loc_nu
+                AND c BETWEEN fb.fcb_cep_ini AND fb.fcb_cep_fim
+            ORDER BY
+                fb.fcb_cep_fim::int - fb.fcb_cep_ini::int
+            LIMIT 1)::text AS bairro,
         NULL::text AS complemento,
-        lgu.gru_endereco::text AS logradouro
-    FROM
-        correios.log_grande_usuario lgu
-        JOIN correios.log_localidade ll ON ll.loc_nu = lgu.loc_nu
-        LEFT JOIN correios.log_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub
-            AND ll.loc_in_tipo_loc <> 'M'
-        LEFT JOIN correios.log_bairro lb ON lb.bai_nu = lgu.bai_nu
-    WHERE
-        lgu.cep = c
-    UNION
-    SELECT
-        luo.ufe_sg::text AS uf,
-        (
-            CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
-                ll.loc_no
-            ELSE
-                ll2.loc_no
-            END)::text AS localidade,
-        luo.cep::text,
+        NULL::text AS logradouro,
+        NULL::text AS nome,
         (
-            CASE WHEN ll.loc_in_tipo_loc = 'M' THEN
-                ll.mun_nu
+            CASE WHEN ll.loc_nu IS NULL THEN
+                'log_faixa_uf'
             ELSE
-                ll2.mun_nu
-            END)::text AS ibge,
-        lb.bai_no::text AS bairro,
-        NULL::text AS complemento,
-        luo.uop_endereco::text AS logradouro
+                'log_faixa_localidade'
+            END)::text AS fonte
     FROM
-        correios.log_unid_oper luo
-        JOIN correios.log_localidade ll ON ll.loc_nu = luo.loc_nu
-        LEFT JOIN correios.log_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub
+        %[2]s.log_faixa_uf fu
+        LEFT JOIN %[2]s.log_localidade ll ON ll.loc_nu = (
+            SELECT
+                fl.loc_nu
+            FROM
+                %[2]s.log_faixa_localidade fl
+            WHERE
+                c BETWEEN fl.loc_cep_ini AND fl.loc_cep_fim
+            ORDER BY
+                fl.loc_cep_fim::int - fl.loc_cep_ini::int
+            LIMIT 1)
+        LEFT JOIN %[2]s.log_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub
             AND ll.loc_in_tipo_loc <> 'M'
-        LEFT JOIN correios.log_bairro lb ON lb.bai_nu = luo.bai_nu
     WHERE
-        luo.cep = c;
+        c BETWEEN fu.ufe_cep_ini AND fu.ufe_cep_fim
+    LIMIT 1;
     END;
     $function$
-    ;`
+    ;`, db.stagingSchema(), db.schema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
-		return fmt.Errorf("erro ao criar função consulta_cep: %w", err)
+		return fmt.Errorf("erro ao criar função consulta_faixa_cep: %w", err)
 	}
 	return nil
 }
 
 func (db *DB) createTableImportacaoRelatorio() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.importacao_relatorio (
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.importacao_relatorio (
 		id serial PRIMARY KEY,
 		executado_em timestamp NOT NULL DEFAULT now(),
 		total_registros int NOT NULL,
@@ -400,14 +1505,40 @@ func (db *DB) createTableImportacaoRelatorio() error {
 		observacoes text
 	);
 
-	COMMENT ON TABLE correios.importacao_relatorio IS 'Relatório consolidado das execuções de importação da base dos Correios';
-	COMMENT ON COLUMN correios.importacao_relatorio.executado_em IS 'Data/hora em que a importação foi concluída';
-	COMMENT ON COLUMN correios.importacao_relatorio.total_registros IS 'Soma total de registros inseridos nas tabelas da base';
-	COMMENT ON COLUMN correios.importacao_relatorio.total_ceps IS 'Quantidade total de CEPs distintos inseridos';
-	COMMENT ON COLUMN correios.importacao_relatorio.versao_base IS 'Versão da base eDNE importada';
-	COMMENT ON COLUMN correios.importacao_relatorio.duracao IS 'Duração total da execução da importação';
-	COMMENT ON COLUMN correios.importacao_relatorio.observacoes IS 'Campo livre para anotações da execução';
-	`
+	ALTER TABLE %[1]s.importacao_relatorio ADD COLUMN IF NOT EXISTS tipo varchar(10) NOT NULL DEFAULT 'basico';
+	ALTER TABLE %[1]s.importacao_relatorio ADD COLUMN IF NOT EXISTS situacao varchar(20) NOT NULL DEFAULT 'concluida';
+
+	COMMENT ON TABLE %[1]s.importacao_relatorio IS 'Relatório consolidado das execuções de importação da base dos Correios';
+	COMMENT ON COLUMN %[1]s.importacao_relatorio.executado_em IS 'Data/hora em que a importação foi concluída';
+	COMMENT ON COLUMN %[1]s.importacao_relatorio.total_registros IS 'Soma total de registros inseridos nas tabelas da base';
+	COMMENT ON COLUMN %[1]s.importacao_relatorio.total_ceps IS 'Quantidade total de CEPs distintos inseridos';
+	COMMENT ON COLUMN %[1]s.importacao_relatorio.versao_base IS 'Versão da base eDNE importada';
+	COMMENT ON COLUMN %[1]s.importacao_relatorio.duracao IS 'Duração total da execução da importação';
+	COMMENT ON COLUMN %[1]s.importacao_relatorio.observacoes IS 'Campo livre para anotações da execução';
+	COMMENT ON COLUMN %[1]s.importacao_relatorio.tipo IS 'Tipo da importação: basico = base completa, delta = atualização incremental';
+	COMMENT ON COLUMN %[1]s.importacao_relatorio.situacao IS 'Situação da execução: concluida, cancelada (SIGINT/SIGTERM), falha ou pendente (delta aplicado sem atualizar cep_enderecos)';
+
+	CREATE TABLE IF NOT EXISTS %[1]s.importacao_arquivo (
+		id serial PRIMARY KEY,
+		importacao_id int NOT NULL REFERENCES %[1]s.importacao_relatorio (id) ON DELETE CASCADE,
+		arquivo varchar(100) NOT NULL,
+		linhas int NOT NULL,
+		registros int NOT NULL,
+		bytes bigint NOT NULL,
+		checksum char(64),
+		duracao interval NOT NULL,
+		erro text
+	);
+
+	COMMENT ON TABLE %[1]s.importacao_arquivo IS 'Relatório por arquivo de cada execução registrada em importacao_relatorio';
+	COMMENT ON COLUMN %[1]s.importacao_arquivo.importacao_id IS 'Execução em importacao_relatorio à qual o arquivo pertence';
+	COMMENT ON COLUMN %[1]s.importacao_arquivo.linhas IS 'Quantidade de linhas lidas do arquivo';
+	COMMENT ON COLUMN %[1]s.importacao_arquivo.registros IS 'Quantidade de registros gravados no banco';
+	COMMENT ON COLUMN %[1]s.importacao_arquivo.bytes IS 'Quantidade de bytes lidos do arquivo';
+	COMMENT ON COLUMN %[1]s.importacao_arquivo.checksum IS 'SHA-256 do arquivo, quando lido por completo';
+	COMMENT ON COLUMN %[1]s.importacao_arquivo.duracao IS 'Duração do processamento do arquivo';
+	COMMENT ON COLUMN %[1]s.importacao_arquivo.erro IS 'Erro que interrompeu o processamento do arquivo';
+	`, db.schema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -416,18 +1547,52 @@ func (db *DB) createTableImportacaoRelatorio() error {
 	return nil
 }
 
+func (db *DB) createTableCepHistorico() error {
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.cep_historico (
+		cep text NOT NULL,
+		uf text NOT NULL,
+		localidade text,
+		ibge text,
+		bairro text,
+		complemento text,
+		logradouro text,
+		nome text,
+		fonte text NOT NULL,
+		versao_de varchar(100) NOT NULL,
+		versao_ate varchar(100),
+		valido_de timestamptz NOT NULL,
+		valido_ate timestamptz,
+		PRIMARY KEY (cep, valido_de)
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS cep_historico_vigente_idx ON %[1]s.cep_historico (cep) WHERE valido_ate IS NULL;
+
+	COMMENT ON TABLE %[1]s.cep_historico IS 'Versões de cada CEP de cep_enderecos com o período de validade (mantido com -history)';
+	COMMENT ON COLUMN %[1]s.cep_historico.versao_de IS 'Versão da base eDNE em que o CEP passou a ter estes dados';
+	COMMENT ON COLUMN %[1]s.cep_historico.versao_ate IS 'Versão da base eDNE em que o CEP foi alterado ou removido';
+	COMMENT ON COLUMN %[1]s.cep_historico.valido_de IS 'Data/hora da importação que abriu a versão';
+	COMMENT ON COLUMN %[1]s.cep_historico.valido_ate IS 'Data/hora da importação que encerrou a versão (nulo = vigente)';
+	`, db.schema())
+
+	_, err := db.pool.Exec(db.ctx, query)
+	if err != nil {
+		return fmt.Errorf("error creating cep_historico table: %w", err)
+	}
+	return nil
+}
+
 func (db *DB) createTableLogFaixaUF() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.log_faixa_uf(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.log_faixa_uf(
 		ufe_sg char(2) NOT NULL,
 		ufe_cep_ini char(8) NOT NULL,
 		ufe_cep_fim char(8) NOT NULL,
 		PRIMARY KEY (ufe_sg, ufe_cep_ini)
 	);
-	COMMENT on column correios.log_faixa_uf.ufe_sg is 'sigla da UF';
-	COMMENT on column correios.log_faixa_uf.ufe_cep_ini is 'CEP inicial da UF';
-	COMMENT on column correios.log_faixa_uf.ufe_cep_fim is 'CEP final da UF';
-	`
+	COMMENT on column %[1]s.log_faixa_uf.ufe_sg is 'sigla da UF';
+	COMMENT on column %[1]s.log_faixa_uf.ufe_cep_ini is 'CEP inicial da UF';
+	COMMENT on column %[1]s.log_faixa_uf.ufe_cep_fim is 'CEP final da UF';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -436,22 +1601,9 @@ func (db *DB) createTableLogFaixaUF() error {
 	return nil
 }
 
-func (db *DB) bulkInsertLogFaixaUF(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "log_faixa_uf"},
-		[]string{"ufe_sg", "ufe_cep_ini", "ufe_cep_fim"},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into log_faixa_uf: %w", err)
-	}
-	return nil
-}
-
 func (db *DB) createTableLogLocalidade() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.log_localidade(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.log_localidade(
 		loc_nu numeric NOT NULL,
 		ufe_sg char(2) NOT NULL,
 		loc_no varchar(72) NOT NULL,
@@ -463,16 +1615,16 @@ func (db *DB) createTableLogLocalidade() error {
 		mun_nu char(7) NULL,
 		PRIMARY KEY (loc_nu)
 	);
-	COMMENT on column correios.log_localidade.loc_nu is 'chave da localidade';
-	COMMENT on column correios.log_localidade.ufe_sg is 'sigla da UF';
-	COMMENT on column correios.log_localidade.loc_no is 'nome da localidade';
-	COMMENT on column correios.log_localidade.cep is 'CEP da localidade (para localidade não codificada, ou seja loc_in_sit = 0)';
-	COMMENT on column correios.log_localidade.loc_in_sit is '0 = Localidade não codificada em nível de Logradouro,1 = Localidade codificada em nível de Logradouro, 2 = Distrito ou Povoado inserido na codificação em nível de Logradouro, 3 = Localidade em fase de codificação em nível de Logradouro.';
-	COMMENT on column correios.log_localidade.loc_in_tipo_loc is 'tipo de localidade: D – Distrito,M – Município,P – Povoado.';
-	COMMENT on column correios.log_localidade.loc_nu_sub is 'chave da localidade de subordinação';
-	COMMENT on column correios.log_localidade.loc_no_abrev is 'abreviatura do nome da localidade';
-	COMMENT on column correios.log_localidade.mun_nu is 'Código do município IBGE';
-	`
+	COMMENT on column %[1]s.log_localidade.loc_nu is 'chave da localidade';
+	COMMENT on column %[1]s.log_localidade.ufe_sg is 'sigla da UF';
+	COMMENT on column %[1]s.log_localidade.loc_no is 'nome da localidade';
+	COMMENT on column %[1]s.log_localidade.cep is 'CEP da localidade (para localidade não codificada, ou seja loc_in_sit = 0)';
+	COMMENT on column %[1]s.log_localidade.loc_in_sit is '0 = Localidade não codificada em nível de Logradouro,1 = Localidade codificada em nível de Logradouro, 2 = Distrito ou Povoado inserido na codificação em nível de Logradouro, 3 = Localidade em fase de codificação em nível de Logradouro.';
+	COMMENT on column %[1]s.log_localidade.loc_in_tipo_loc is 'tipo de localidade: D – Distrito,M – Município,P – Povoado.';
+	COMMENT on column %[1]s.log_localidade.loc_nu_sub is 'chave da localidade de subordinação';
+	COMMENT on column %[1]s.log_localidade.loc_no_abrev is 'abreviatura do nome da localidade';
+	COMMENT on column %[1]s.log_localidade.mun_nu is 'Código do município IBGE';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -481,35 +1633,18 @@ func (db *DB) createTableLogLocalidade() error {
 	return nil
 }
 
-func (db *DB) bulkInsertLogLocalidade(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "log_localidade"},
-		[]string{
-			"loc_nu", "ufe_sg", "loc_no", "cep",
-			"loc_in_sit", "loc_in_tipo_loc", "loc_nu_sub",
-			"loc_no_abrev", "mun_nu",
-		},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into log_localidade: %w", err)
-	}
-	return nil
-}
-
 func (db *DB) createTableLogVarLoc() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.log_var_loc(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.log_var_loc(
 		loc_nu numeric NOT NULL,
 		val_nu numeric NOT NULL,
 		val_tx varchar(72) NOT NULL,
 		PRIMARY KEY (loc_nu, val_nu)
 	);
-	COMMENT on column correios.log_var_loc.loc_nu is 'chave da localidade';
-	COMMENT on column correios.log_var_loc.val_nu is 'ordem da localidade';
-	COMMENT on column correios.log_var_loc.val_tx is 'Denominação';
-	`
+	COMMENT on column %[1]s.log_var_loc.loc_nu is 'chave da localidade';
+	COMMENT on column %[1]s.log_var_loc.val_nu is 'ordem da localidade';
+	COMMENT on column %[1]s.log_var_loc.val_tx is 'Denominação';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -518,33 +1653,20 @@ func (db *DB) createTableLogVarLoc() error {
 	return nil
 }
 
-func (db *DB) bulkInsertLogVarLoc(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "log_var_loc"},
-		[]string{"loc_nu", "val_nu", "val_tx"},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into log_var_loc: %w", err)
-	}
-	return nil
-}
-
 func (db *DB) createTableLogFaixaLocalidade() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.log_faixa_localidade(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.log_faixa_localidade(
 		loc_nu numeric NOT NULL,
 		loc_cep_ini char(8) NOT NULL,
 		loc_cep_fim char(8) NOT NULL,
 		loc_tipo_faixa char(1) NOT NULL,
 		PRIMARY KEY (loc_nu, loc_cep_ini, loc_tipo_faixa)
 	);
-	COMMENT on column correios.log_faixa_localidade.loc_nu is 'chave da localidade';
-	COMMENT on column correios.log_faixa_localidade.loc_cep_ini is 'CEP inicial da localidade';
-	COMMENT on column correios.log_faixa_localidade.loc_cep_fim is 'CEP final da localidade';
-	COMMENT on column correios.log_faixa_localidade.loc_tipo_faixa is 'tipo de Faixa de CEP:T –Total do Município C – Exclusiva da  Sede Urbana';
-	`
+	COMMENT on column %[1]s.log_faixa_localidade.loc_nu is 'chave da localidade';
+	COMMENT on column %[1]s.log_faixa_localidade.loc_cep_ini is 'CEP inicial da localidade';
+	COMMENT on column %[1]s.log_faixa_localidade.loc_cep_fim is 'CEP final da localidade';
+	COMMENT on column %[1]s.log_faixa_localidade.loc_tipo_faixa is 'tipo de Faixa de CEP:T –Total do Município C – Exclusiva da  Sede Urbana';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -553,35 +1675,22 @@ func (db *DB) createTableLogFaixaLocalidade() error {
 	return nil
 }
 
-func (db *DB) bulkInsertLogFaixaLocalidade(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "log_faixa_localidade"},
-		[]string{"loc_nu", "loc_cep_ini", "loc_cep_fim", "loc_tipo_faixa"},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into log_faixa_localidade: %w", err)
-	}
-	return nil
-}
-
 func (db *DB) createTableLogBairro() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.log_bairro(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.log_bairro(
 		bai_nu numeric NOT NULL,
 		ufe_sg char(2) NOT NULL,
-		loc_nu char(8) NOT NULL,
+		loc_nu numeric NOT NULL,
 		bai_no varchar(72) NOT NULL,
 		bai_no_abrev varchar(36) NULL,
 		PRIMARY KEY (bai_nu)
 	);
-	COMMENT on column correios.log_bairro.bai_nu is 'chave do bairro';
-	COMMENT on column correios.log_bairro.ufe_sg is 'sigla da UF';
-	COMMENT on column correios.log_bairro.loc_nu is 'chave da localidade';
-	COMMENT on column correios.log_bairro.bai_no is 'nome do bairro';
-	COMMENT on column correios.log_bairro.bai_no_abrev is 'abreviatura do nome do bairro';
-	`
+	COMMENT on column %[1]s.log_bairro.bai_nu is 'chave do bairro';
+	COMMENT on column %[1]s.log_bairro.ufe_sg is 'sigla da UF';
+	COMMENT on column %[1]s.log_bairro.loc_nu is 'chave da localidade';
+	COMMENT on column %[1]s.log_bairro.bai_no is 'nome do bairro';
+	COMMENT on column %[1]s.log_bairro.bai_no_abrev is 'abreviatura do nome do bairro';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -590,31 +1699,18 @@ func (db *DB) createTableLogBairro() error {
 	return nil
 }
 
-func (db *DB) bulkInsertLogBairro(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "log_bairro"},
-		[]string{"bai_nu", "ufe_sg", "loc_nu", "bai_no", "bai_no_abrev"},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into log_bairro: %w", err)
-	}
-	return nil
-}
-
 func (db *DB) createTableLogVarBai() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.log_var_bai(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.log_var_bai(
 		bai_nu numeric NOT NULL,
 		vdb_nu char(2) NOT NULL,
 		vdb_tx varchar(72) NOT NULL,
 		PRIMARY KEY (bai_nu, vdb_nu)
 	);
-	COMMENT on column correios.log_var_bai.bai_nu is 'chave do bairro';
-	COMMENT on column correios.log_var_bai.vdb_nu is 'ordem da denominação';
-	COMMENT on column correios.log_var_bai.vdb_tx is 'Denominação';
-	`
+	COMMENT on column %[1]s.log_var_bai.bai_nu is 'chave do bairro';
+	COMMENT on column %[1]s.log_var_bai.vdb_nu is 'ordem da denominação';
+	COMMENT on column %[1]s.log_var_bai.vdb_tx is 'Denominação';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -623,31 +1719,18 @@ func (db *DB) createTableLogVarBai() error {
 	return nil
 }
 
-func (db *DB) bulkInsertLogVarBai(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "log_var_bai"},
-		[]string{"bai_nu", "vdb_nu", "vdb_tx"},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into log_var_bai: %w", err)
-	}
-	return nil
-}
-
 func (db *DB) createTableLogFaixaBairro() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.log_faixa_bairro(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.log_faixa_bairro(
 		bai_nu numeric NOT NULL,
 		fcb_cep_ini char(8) NOT NULL,
 		fcb_cep_fim char(8) NOT NULL,
 		PRIMARY KEY (bai_nu, fcb_cep_ini)
 	);
-	COMMENT on column correios.log_faixa_bairro.bai_nu is 'chave do bairro';
-	COMMENT on column correios.log_faixa_bairro.fcb_cep_ini is 'CEP inicial do bairro';
-	COMMENT on column correios.log_faixa_bairro.fcb_cep_fim is 'CEP final do bairro';
-	`
+	COMMENT on column %[1]s.log_faixa_bairro.bai_nu is 'chave do bairro';
+	COMMENT on column %[1]s.log_faixa_bairro.fcb_cep_ini is 'CEP inicial do bairro';
+	COMMENT on column %[1]s.log_faixa_bairro.fcb_cep_fim is 'CEP final do bairro';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -656,22 +1739,9 @@ func (db *DB) createTableLogFaixaBairro() error {
 	return nil
 }
 
-func (db *DB) bulkInsertLogFaixaBairro(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "log_faixa_bairro"},
-		[]string{"bai_nu", "fcb_cep_ini", "fcb_cep_fim"},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into log_faixa_bairro: %w", err)
-	}
-	return nil
-}
-
 func (db *DB) createTableLogCPC() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.log_cpc(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.log_cpc(
 		cpc_nu numeric NOT NULL,
 		ufe_sg char(2) NOT NULL,
 		loc_nu numeric NOT NULL,
@@ -680,13 +1750,13 @@ func (db *DB) createTableLogCPC() error {
 		cep char(8) NOT NULL,
 		PRIMARY KEY (cpc_nu)
 	);
-	COMMENT on column correios.log_cpc.cpc_nu is 'chave da caixa postal comunitária';
-	COMMENT on column correios.log_cpc.ufe_sg is 'sigla da UF';
-	COMMENT on column correios.log_cpc.loc_nu is 'chave da localidade';
-	COMMENT on column correios.log_cpc.cpc_no is 'nome da CPC';
-	COMMENT on column correios.log_cpc.cpc_endereco is 'endereço da CPC';
-	COMMENT on column correios.log_cpc.cep is 'CEP da CPC';
-	`
+	COMMENT on column %[1]s.log_cpc.cpc_nu is 'chave da caixa postal comunitária';
+	COMMENT on column %[1]s.log_cpc.ufe_sg is 'sigla da UF';
+	COMMENT on column %[1]s.log_cpc.loc_nu is 'chave da localidade';
+	COMMENT on column %[1]s.log_cpc.cpc_no is 'nome da CPC';
+	COMMENT on column %[1]s.log_cpc.cpc_endereco is 'endereço da CPC';
+	COMMENT on column %[1]s.log_cpc.cep is 'CEP da CPC';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -695,31 +1765,18 @@ func (db *DB) createTableLogCPC() error {
 	return nil
 }
 
-func (db *DB) bulkInsertLogCPC(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "log_cpc"},
-		[]string{"cpc_nu", "ufe_sg", "loc_nu", "cpc_no", "cpc_endereco", "cep"},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into log_cpc: %w", err)
-	}
-	return nil
-}
-
 func (db *DB) createTableLogFaixaCPC() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.log_faixa_cpc(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.log_faixa_cpc(
 		cpc_nu numeric NOT NULL,
 		cpc_inicial varchar(6) NOT NULL,
 		cpc_final varchar(6) NOT NULL,
 		PRIMARY KEY (cpc_nu, cpc_inicial)
 	);
-	COMMENT on column correios.log_faixa_cpc.cpc_nu is 'chave da caixa postal comunitária';
-	COMMENT on column correios.log_faixa_cpc.cpc_inicial is 'número inicial da caixa postal comunitária';
-	COMMENT on column correios.log_faixa_cpc.cpc_final is 'número final da caixa postal comunitária';
-	`
+	COMMENT on column %[1]s.log_faixa_cpc.cpc_nu is 'chave da caixa postal comunitária';
+	COMMENT on column %[1]s.log_faixa_cpc.cpc_inicial is 'número inicial da caixa postal comunitária';
+	COMMENT on column %[1]s.log_faixa_cpc.cpc_final is 'número final da caixa postal comunitária';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -728,22 +1785,9 @@ func (db *DB) createTableLogFaixaCPC() error {
 	return nil
 }
 
-func (db *DB) bulkInsertLogFaixaCPC(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "log_faixa_cpc"},
-		[]string{"cpc_nu", "cpc_inicial", "cpc_final"},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into log_faixa_cpc: %w", err)
-	}
-	return nil
-}
-
 func (db *DB) createTableLogLogradouro() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.log_logradouro(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.log_logradouro(
 		log_nu numeric NOT NULL,
 		ufe_sg char(2) NOT NULL,
 		loc_nu numeric NOT NULL,
@@ -757,18 +1801,18 @@ func (db *DB) createTableLogLogradouro() error {
 		log_no_abrev varchar(100) NULL,
 		PRIMARY KEY (log_nu)
 	);
-	COMMENT on column correios.log_logradouro.log_nu is 'chave do logradouro';
-	COMMENT on column correios.log_logradouro.ufe_sg is 'sigla da UF';
-	COMMENT on column correios.log_logradouro.loc_nu is 'chave da localidade';
-	COMMENT on column correios.log_logradouro.bai_nu_ini is 'chave do bairro inicial do logradouro';
-	COMMENT on column correios.log_logradouro.bai_nu_fim is 'chave do bairro final do logradouro';
-	COMMENT on column correios.log_logradouro.log_no is 'nome do logradouro';
-	COMMENT on column correios.log_logradouro.log_complemento is 'complemento do logradouro';
-	COMMENT on column correios.log_logradouro.cep is 'CEP do logradouro';
-	COMMENT on column correios.log_logradouro.tlo_tx is 'tipo de logradouro';
-	COMMENT on column correios.log_logradouro.log_sta_tlo is 'indicador de utilização do tipo de logradouro (S ou N)';
-	COMMENT on column correios.log_logradouro.log_no_abrev is 'abreviatura do nome do logradouro';
-	`
+	COMMENT on column %[1]s.log_logradouro.log_nu is 'chave do logradouro';
+	COMMENT on column %[1]s.log_logradouro.ufe_sg is 'sigla da UF';
+	COMMENT on column %[1]s.log_logradouro.loc_nu is 'chave da localidade';
+	COMMENT on column %[1]s.log_logradouro.bai_nu_ini is 'chave do bairro inicial do logradouro';
+	COMMENT on column %[1]s.log_logradouro.bai_nu_fim is 'chave do bairro final do logradouro';
+	COMMENT on column %[1]s.log_logradouro.log_no is 'nome do logradouro';
+	COMMENT on column %[1]s.log_logradouro.log_complemento is 'complemento do logradouro';
+	COMMENT on column %[1]s.log_logradouro.cep is 'CEP do logradouro';
+	COMMENT on column %[1]s.log_logradouro.tlo_tx is 'tipo de logradouro';
+	COMMENT on column %[1]s.log_logradouro.log_sta_tlo is 'indicador de utilização do tipo de logradouro (S ou N)';
+	COMMENT on column %[1]s.log_logradouro.log_no_abrev is 'abreviatura do nome do logradouro';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -777,34 +1821,20 @@ func (db *DB) createTableLogLogradouro() error {
 	return nil
 }
 
-func (db *DB) bulkInsertLogLogradouro(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "log_logradouro"},
-		[]string{"log_nu", "ufe_sg", "loc_nu", "bai_nu_ini", "bai_nu_fim",
-			"log_no", "log_complemento", "cep", "tlo_tx", "log_sta_tlo", "log_no_abrev"},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into log_logradouro: %w", err)
-	}
-	return nil
-}
-
 func (db *DB) createTableLogVarLog() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.log_var_log(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.log_var_log(
 		log_nu numeric NOT NULL,
 		vlo_nu numeric NOT NULL,
 		tlo_tx varchar(36) NOT NULL,
 		vlo_tx varchar(150) NOT NULL,
 		PRIMARY KEY (log_nu, vlo_nu)
 	);
-	COMMENT on column correios.log_var_log.log_nu is 'chave do logradouro';
-	COMMENT on column correios.log_var_log.vlo_nu is 'ordem da denominação';
-	COMMENT on column correios.log_var_log.tlo_tx is 'tipo de logradouro da variação';
-	COMMENT on column correios.log_var_log.vlo_tx is 'nome da variação do logradouro';
-	`
+	COMMENT on column %[1]s.log_var_log.log_nu is 'chave do logradouro';
+	COMMENT on column %[1]s.log_var_log.vlo_nu is 'ordem da denominação';
+	COMMENT on column %[1]s.log_var_log.tlo_tx is 'tipo de logradouro da variação';
+	COMMENT on column %[1]s.log_var_log.vlo_tx is 'nome da variação do logradouro';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -813,33 +1843,20 @@ func (db *DB) createTableLogVarLog() error {
 	return nil
 }
 
-func (db *DB) bulkInsertLogVarLog(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "log_var_log"},
-		[]string{"log_nu", "vlo_nu", "tlo_tx", "vlo_tx"},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into log_var_log: %w", err)
-	}
-	return nil
-}
-
 func (db *DB) createTableLogNumSec() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.log_num_sec(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.log_num_sec(
 		log_nu numeric NOT NULL,
 		sec_nu_ini varchar(10) NOT NULL,
 		sec_nu_fim varchar(10) NOT NULL,
 		sec_in_lado char(1) NOT NULL,
 		PRIMARY KEY (log_nu)
 	);
-	COMMENT on column correios.log_num_sec.log_nu is 'chave do logradouro';
-	COMMENT on column correios.log_num_sec.sec_nu_ini is 'número inicial do seccionamento';
-	COMMENT on column correios.log_num_sec.sec_nu_fim is 'número final do seccionamento';
-	COMMENT on column correios.log_num_sec.sec_in_lado is 'Indica a paridade/lado do seccionamento A – ambos,P – par,I – ímpar,D – direito eE – esquerdo.';
-	`
+	COMMENT on column %[1]s.log_num_sec.log_nu is 'chave do logradouro';
+	COMMENT on column %[1]s.log_num_sec.sec_nu_ini is 'número inicial do seccionamento';
+	COMMENT on column %[1]s.log_num_sec.sec_nu_fim is 'número final do seccionamento';
+	COMMENT on column %[1]s.log_num_sec.sec_in_lado is 'Indica a paridade/lado do seccionamento A – ambos,P – par,I – ímpar,D – direito eE – esquerdo.';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -848,22 +1865,9 @@ func (db *DB) createTableLogNumSec() error {
 	return nil
 }
 
-func (db *DB) bulkInsertLogNumSec(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "log_num_sec"},
-		[]string{"log_nu", "sec_nu_ini", "sec_nu_fim", "sec_in_lado"},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into log_num_sec: %w", err)
-	}
-	return nil
-}
-
 func (db *DB) createTableLogGrandeUsuario() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.log_grande_usuario(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.log_grande_usuario(
 		gru_nu numeric NOT NULL,
 		ufe_sg char(2) NOT NULL,
 		loc_nu numeric NOT NULL,
@@ -875,16 +1879,16 @@ func (db *DB) createTableLogGrandeUsuario() error {
 		gru_no_abrev varchar(255) NULL,
 		PRIMARY KEY (gru_nu)
 	);
-	COMMENT on column correios.log_grande_usuario.gru_nu is 'chave do grande usuário';
-	COMMENT on column correios.log_grande_usuario.ufe_sg is 'sigla da UF';
-	COMMENT on column correios.log_grande_usuario.loc_nu is 'chave da localidade';
-	COMMENT on column correios.log_grande_usuario.bai_nu is 'chave do bairro';
-	COMMENT on column correios.log_grande_usuario.log_nu is 'chave do logradouro';
-	COMMENT on column correios.log_grande_usuario.gru_no is 'nome do grande usuário';
-	COMMENT on column correios.log_grande_usuario.gru_endereco is 'endereço do grande usuário';
-	COMMENT on column correios.log_grande_usuario.cep is 'CEP do grande usuário';
-	COMMENT on column correios.log_grande_usuario.gru_no_abrev is 'abreviatura do nome do grande usuário';
-	`
+	COMMENT on column %[1]s.log_grande_usuario.gru_nu is 'chave do grande usuário';
+	COMMENT on column %[1]s.log_grande_usuario.ufe_sg is 'sigla da UF';
+	COMMENT on column %[1]s.log_grande_usuario.loc_nu is 'chave da localidade';
+	COMMENT on column %[1]s.log_grande_usuario.bai_nu is 'chave do bairro';
+	COMMENT on column %[1]s.log_grande_usuario.log_nu is 'chave do logradouro';
+	COMMENT on column %[1]s.log_grande_usuario.gru_no is 'nome do grande usuário';
+	COMMENT on column %[1]s.log_grande_usuario.gru_endereco is 'endereço do grande usuário';
+	COMMENT on column %[1]s.log_grande_usuario.cep is 'CEP do grande usuário';
+	COMMENT on column %[1]s.log_grande_usuario.gru_no_abrev is 'abreviatura do nome do grande usuário';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -893,23 +1897,9 @@ func (db *DB) createTableLogGrandeUsuario() error {
 	return nil
 }
 
-func (db *DB) bulkInsertLogGrandeUsuario(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "log_grande_usuario"},
-		[]string{"gru_nu", "ufe_sg", "loc_nu", "bai_nu", "log_nu",
-			"gru_no", "gru_endereco", "cep", "gru_no_abrev"},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into log_grande_usuario: %w", err)
-	}
-	return nil
-}
-
 func (db *DB) createTableLogUnidOper() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.log_unid_oper(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.log_unid_oper(
 		uop_nu numeric NOT NULL,
 		ufe_sg char(2) NOT NULL,
 		loc_nu numeric NOT NULL,
@@ -922,17 +1912,17 @@ func (db *DB) createTableLogUnidOper() error {
 		uop_no_abrev varchar(100) NULL,
 		PRIMARY KEY (uop_nu)
 	);
-	COMMENT on column correios.log_unid_oper.uop_nu is 'chave da UOP';
-	COMMENT on column correios.log_unid_oper.ufe_sg is 'sigla da UF';
-	COMMENT on column correios.log_unid_oper.loc_nu is 'chave da localidade';
-	COMMENT on column correios.log_unid_oper.bai_nu is 'chave do bairro';
-	COMMENT on column correios.log_unid_oper.log_nu is 'chave do logradouro';
-	COMMENT on column correios.log_unid_oper.uop_no is 'nome da UOP';
-	COMMENT on column correios.log_unid_oper.uop_endereco is 'endereço da UOP';
-	COMMENT on column correios.log_unid_oper.cep is 'CEP da UOP';
-	COMMENT on column correios.log_unid_oper.uop_in_cp is 'indicador de caixa postal (S ou N)';
-	COMMENT on column correios.log_unid_oper.uop_no_abrev is 'abreviatura do nome da unid. operacional';
-	`
+	COMMENT on column %[1]s.log_unid_oper.uop_nu is 'chave da UOP';
+	COMMENT on column %[1]s.log_unid_oper.ufe_sg is 'sigla da UF';
+	COMMENT on column %[1]s.log_unid_oper.loc_nu is 'chave da localidade';
+	COMMENT on column %[1]s.log_unid_oper.bai_nu is 'chave do bairro';
+	COMMENT on column %[1]s.log_unid_oper.log_nu is 'chave do logradouro';
+	COMMENT on column %[1]s.log_unid_oper.uop_no is 'nome da UOP';
+	COMMENT on column %[1]s.log_unid_oper.uop_endereco is 'endereço da UOP';
+	COMMENT on column %[1]s.log_unid_oper.cep is 'CEP da UOP';
+	COMMENT on column %[1]s.log_unid_oper.uop_in_cp is 'indicador de caixa postal (S ou N)';
+	COMMENT on column %[1]s.log_unid_oper.uop_no_abrev is 'abreviatura do nome da unid. operacional';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -941,32 +1931,18 @@ func (db *DB) createTableLogUnidOper() error {
 	return nil
 }
 
-func (db *DB) bulkInsertLogUnidOper(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "log_unid_oper"},
-		[]string{"uop_nu", "ufe_sg", "loc_nu", "bai_nu", "log_nu",
-			"uop_no", "uop_endereco", "cep", "uop_in_cp", "uop_no_abrev"},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into log_unid_oper: %w", err)
-	}
-	return nil
-}
-
 func (db *DB) createTableLogFaixaUOP() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.log_faixa_uop(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.log_faixa_uop(
 		uop_nu numeric NOT NULL,
 		fnc_inicial numeric NOT NULL,
 		fnc_final numeric NOT NULL,
 		PRIMARY KEY (uop_nu, fnc_inicial)
 	);
-	COMMENT on column correios.log_faixa_uop.uop_nu is 'chave da UOP';
-	COMMENT on column correios.log_faixa_uop.fnc_inicial is 'número inicial da caixa postal';
-	COMMENT on column correios.log_faixa_uop.fnc_final is 'número final da caixa postal';
-	`
+	COMMENT on column %[1]s.log_faixa_uop.uop_nu is 'chave da UOP';
+	COMMENT on column %[1]s.log_faixa_uop.fnc_inicial is 'número inicial da caixa postal';
+	COMMENT on column %[1]s.log_faixa_uop.fnc_final is 'número final da caixa postal';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -975,22 +1951,9 @@ func (db *DB) createTableLogFaixaUOP() error {
 	return nil
 }
 
-func (db *DB) bulkInsertLogFaixaUOP(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "log_faixa_uop"},
-		[]string{"uop_nu", "fnc_inicial", "fnc_final"},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into log_faixa_uop: %w", err)
-	}
-	return nil
-}
-
 func (db *DB) createTableECTPais() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS correios.ect_pais(
+	query := fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %[1]s.ect_pais(
 		pai_sg char(2) NOT NULL,
 		pai_sg_alternativa char(3) NOT NULL,
 		pai_no_portugues varchar(100) NOT NULL,
@@ -999,9 +1962,9 @@ func (db *DB) createTableECTPais() error {
 		pai_abreviatura varchar(100) NOT NULL,
 		PRIMARY KEY (pai_sg)
 	);
-	COMMENT on column correios.ect_pais.pai_sg is 'Sigla do País';
-	COMMENT on column correios.ect_pais.pai_sg_alternativa is 'Sigla alternativa';
-	`
+	COMMENT on column %[1]s.ect_pais.pai_sg is 'Sigla do País';
+	COMMENT on column %[1]s.ect_pais.pai_sg_alternativa is 'Sigla alternativa';
+	`, db.stagingSchema())
 
 	_, err := db.pool.Exec(db.ctx, query)
 	if err != nil {
@@ -1009,17 +1972,3 @@ func (db *DB) createTableECTPais() error {
 	}
 	return nil
 }
-
-func (db *DB) bulkInsertECTPais(rows [][]any) error {
-	_, err := db.pool.CopyFrom(
-		db.ctx,
-		pgx.Identifier{"correios", "ect_pais"},
-		[]string{"pai_sg", "pai_sg_alternativa", "pai_no_portugues",
-			"pai_no_ingles", "pai_no_frances", "pai_abreviatura"},
-		pgx.CopyFromRows(rows),
-	)
-	if err != nil {
-		return fmt.Errorf("error bulk inserting into ect_pais: %w", err)
-	}
-	return nil
-}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+)
+
+func TestDeltaQueries(t *testing.T) {
+	tests := []struct {
+		file   string
+		upsert string
+		remove string
+	}{
+		{
+			file: "DELTA_LOG_BAIRRO.TXT",
+			upsert: `INSERT INTO "correios"."log_bairro" ("bai_nu", "ufe_sg", "loc_nu", "bai_no", "bai_no_abrev") ` +
+				`VALUES ($1, $2, $3, $4, $5) ON CONFLICT ("bai_nu") DO UPDATE SET ` +
+				`"ufe_sg" = EXCLUDED."ufe_sg", "loc_nu" = EXCLUDED."loc_nu", "bai_no" = EXCLUDED."bai_no", "bai_no_abrev" = EXCLUDED."bai_no_abrev"`,
+			remove: `DELETE FROM "correios"."log_bairro" WHERE "bai_nu" = $1`,
+		},
+		{
+			file: "DELTA_LOG_FAIXA_UF.TXT",
+			upsert: `INSERT INTO "correios"."log_faixa_uf" ("ufe_sg", "ufe_cep_ini", "ufe_cep_fim") ` +
+				`VALUES ($1, $2, $3) ON CONFLICT ("ufe_sg", "ufe_cep_ini") DO UPDATE SET "ufe_cep_fim" = EXCLUDED."ufe_cep_fim"`,
+			remove: `DELETE FROM "correios"."log_faixa_uf" WHERE "ufe_sg" = $1 AND "ufe_cep_ini" = $2`,
+		},
+	}
+
+	for _, test := range tests {
+		table, ok := types.TableForFile(test.file)
+		if !ok {
+			t.Fatalf("tabela de %s não encontrada", test.file)
+		}
+
+		upsert, remove := deltaQueries(DefaultSchema, table)
+		if upsert != test.upsert {
+			t.Errorf("%s: upsert esperado\n%s\nobtido\n%s", test.file, test.upsert, upsert)
+		}
+
+		if remove != test.remove {
+			t.Errorf("%s: delete esperado\n%s\nobtido\n%s", test.file, test.remove, remove)
+		}
+	}
+}
+
+func TestPrimaryKeyValues(t *testing.T) {
+	table, _ := types.TableForFile("LOG_FAIXA_UF.TXT")
+	keys := primaryKeyValues(table, []any{"PR", "80000000", "87999999"})
+
+	if len(keys) != 2 || keys[0] != "PR" || keys[1] != "80000000" {
+		t.Errorf("chave esperada [PR 80000000], obtida %v", keys)
+	}
+}
@@ -0,0 +1,266 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	immu "github.com/diegodario88/importador-cep-correios/pkg/constants"
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+	work "github.com/diegodario88/importador-cep-correios/pkg/workers"
+)
+
+// Origem das linhas de uma tabela, no mesmo formato de Storage.StreamTable.
+type RowSource func(ctx context.Context, table types.Table, handle func(row []any) error) error
+
+type Change struct {
+	Tabela   string         `json:"tabela"`
+	Operacao string         `json:"operacao"`
+	Chave    map[string]any `json:"chave"`
+	Antes    map[string]any `json:"antes,omitempty"`
+	Depois   map[string]any `json:"depois,omitempty"`
+}
+
+type Summary struct {
+	Table     string
+	Inseridos int
+	Removidos int
+	Alterados int
+	Colunas   map[string]int
+}
+
+// Lê as linhas dos arquivos da tabela em uma base eDNE (diretório ou .zip).
+// Os arquivos não vêm ordenados pela chave primária, então as linhas passam
+// pelo sorter antes de chegar a handle.
+func SourceRows(source fs.FS) RowSource {
+	return func(ctx context.Context, table types.Table, handle func(row []any) error) error {
+		matches, err := fs.Glob(source, table.File)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar arquivos %s: %w", table.File, err)
+		}
+
+		if len(matches) == 0 {
+			return fmt.Errorf("padrão %s não encontrou arquivos", table.File)
+		}
+
+		sorter := newSorter(table, keyIndexes(table))
+		defer sorter.close()
+
+		for _, filePath := range matches {
+			if err := work.ReadRows(ctx, source, path.Base(filePath), sorter.add); err != nil {
+				return err
+			}
+		}
+		return sorter.emit(ctx, handle)
+	}
+}
+
+// Compara a tabela intercalando as duas origens, ambas ordenadas pela chave
+// primária (ver compareKeys): só a linha corrente de cada lado fica em
+// memória, e as alterações saem na ordem da chave.
+func Table(ctx context.Context, table types.Table, base RowSource, target RowSource, emit func(Change) error) (Summary, error) {
+	summary := Summary{Table: table.Name, Colunas: make(map[string]int)}
+	columns := table.ColumnNames()
+	keys := keyIndexes(table)
+
+	toMap := func(row []any, only []int) map[string]any {
+		values := make(map[string]any, len(only))
+		for _, index := range only {
+			values[columns[index]] = row[index]
+		}
+		return values
+	}
+
+	allIndexes := make([]int, len(columns))
+	for i := range columns {
+		allIndexes[i] = i
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	previous := startStream(ctx, table, keys, base)
+	next := startStream(ctx, table, keys, target)
+	defer func() {
+		cancel()
+		previous.drain()
+		next.drain()
+	}()
+
+	old, err := previous.next()
+	if err != nil {
+		return summary, fmt.Errorf("erro ao ler base de %s: %w", table.Name, err)
+	}
+
+	current, err := next.next()
+	if err != nil {
+		return summary, fmt.Errorf("erro ao ler nova versão de %s: %w", table.Name, err)
+	}
+
+	for old != nil || current != nil {
+		var order int
+		switch {
+		case old == nil:
+			order = 1
+		case current == nil:
+			order = -1
+		default:
+			order = compareKeys(table, keys, old, current)
+		}
+
+		switch {
+		case order < 0:
+			summary.Removidos++
+			err := emit(Change{
+				Tabela:   table.Name,
+				Operacao: immu.DELTA_DELETE,
+				Chave:    toMap(old, keys),
+				Antes:    toMap(old, allIndexes),
+			})
+			if err != nil {
+				return summary, err
+			}
+		case order > 0:
+			summary.Inseridos++
+			err := emit(Change{
+				Tabela:   table.Name,
+				Operacao: immu.DELTA_INSERT,
+				Chave:    toMap(current, keys),
+				Depois:   toMap(current, allIndexes),
+			})
+			if err != nil {
+				return summary, err
+			}
+		default:
+			var changed []int
+			for i := range columns {
+				if old[i] != current[i] {
+					changed = append(changed, i)
+					summary.Colunas[columns[i]]++
+				}
+			}
+
+			if len(changed) > 0 {
+				summary.Alterados++
+				err := emit(Change{
+					Tabela:   table.Name,
+					Operacao: immu.DELTA_UPDATE,
+					Chave:    toMap(current, keys),
+					Antes:    toMap(old, changed),
+					Depois:   toMap(current, changed),
+				})
+				if err != nil {
+					return summary, err
+				}
+			}
+		}
+
+		if order <= 0 {
+			if old, err = previous.next(); err != nil {
+				return summary, fmt.Errorf("erro ao ler base de %s: %w", table.Name, err)
+			}
+		}
+
+		if order >= 0 {
+			if current, err = next.next(); err != nil {
+				return summary, fmt.Errorf("erro ao ler nova versão de %s: %w", table.Name, err)
+			}
+		}
+	}
+
+	return summary, nil
+}
+
+func keyIndexes(table types.Table) []int {
+	columns := table.ColumnNames()
+	indexes := make([]int, len(table.PrimaryKey))
+	for i, column := range table.PrimaryKey {
+		indexes[i] = slices.Index(columns, column)
+	}
+	return indexes
+}
+
+// Lê uma RowSource em uma goroutine para que Table consuma as duas origens
+// linha a linha.
+type stream struct {
+	table types.Table
+	keys  []int
+	rows  chan []any
+	errc  chan error
+	last  []any
+}
+
+func startStream(ctx context.Context, table types.Table, keys []int, source RowSource) *stream {
+	s := &stream{
+		table: table,
+		keys:  keys,
+		rows:  make(chan []any, 256),
+		errc:  make(chan error, 1),
+	}
+
+	go func() {
+		defer close(s.rows)
+		s.errc <- source(ctx, table, func(row []any) error {
+			normalized, err := normalize(table, row)
+			if err != nil {
+				return err
+			}
+
+			select {
+			case s.rows <- normalized:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	return s
+}
+
+// Devolve a próxima linha, ou nil ao fim da origem. A intercalação só é
+// correta com as linhas em ordem crescente e sem chaves repetidas.
+func (s *stream) next() ([]any, error) {
+	row, ok := <-s.rows
+	if !ok {
+		return nil, <-s.errc
+	}
+
+	if s.last != nil && compareKeys(s.table, s.keys, s.last, row) >= 0 {
+		key := make([]any, len(s.keys))
+		for i, index := range s.keys {
+			key[i] = row[index]
+		}
+		return nil, fmt.Errorf("chave %v fora de ordem ou repetida", key)
+	}
+
+	s.last = row
+	return row, nil
+}
+
+func (s *stream) drain() {
+	for range s.rows {
+	}
+}
+
+// O banco devolve as colunas como texto e os arquivos como strings já
+// aparadas; ambos são reduzidos a string ou nil (vazio conta como nil) para a
+// comparação.
+func normalize(table types.Table, row []any) ([]any, error) {
+	if len(row) != len(table.Columns) {
+		return nil, fmt.Errorf("%s: esperadas %d colunas, encontradas %d", table.Name, len(table.Columns), len(row))
+	}
+
+	normalized := make([]any, len(row))
+	for i, value := range row {
+		if value == nil {
+			continue
+		}
+
+		if text := strings.TrimSpace(fmt.Sprint(value)); text != "" {
+			normalized[i] = text
+		}
+	}
+	return normalized, nil
+}
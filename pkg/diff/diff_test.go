@@ -0,0 +1,139 @@
+package diff
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	immu "github.com/diegodario88/importador-cep-correios/pkg/constants"
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+)
+
+func TestTable(t *testing.T) {
+	table, _ := types.TableByName("log_bairro")
+	base := fstest.MapFS{"LOG_BAIRRO.TXT": {Data: []byte(
+		"10@PR@1@Centro@Centro\n" +
+			"11@PR@1@Zona 7@Zona 7\n" +
+			"13@PR@1@Zona 5@\n")}}
+	target := fstest.MapFS{"LOG_BAIRRO.TXT": {Data: []byte(
+		// Os arquivos eDNE são distribuídos em ISO-8859-1 e sem ordem.
+		"12@PR@1@Zona 2@Zona 2\n" +
+			"13@PR@1@Zona 5@ \n" +
+			"10@PR@1@Centro C\xedvico@Centro\n")}}
+
+	var changes []Change
+	summary, err := Table(context.Background(), table, SourceRows(base), SourceRows(target), func(change Change) error {
+		changes = append(changes, change)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Change{
+		{
+			Tabela:   "log_bairro",
+			Operacao: immu.DELTA_UPDATE,
+			Chave:    map[string]any{"bai_nu": "10"},
+			Antes:    map[string]any{"bai_no": "Centro"},
+			Depois:   map[string]any{"bai_no": "Centro Cívico"},
+		},
+		{
+			Tabela:   "log_bairro",
+			Operacao: immu.DELTA_DELETE,
+			Chave:    map[string]any{"bai_nu": "11"},
+			Antes: map[string]any{
+				"bai_nu": "11", "ufe_sg": "PR", "loc_nu": "1", "bai_no": "Zona 7", "bai_no_abrev": "Zona 7",
+			},
+		},
+		{
+			Tabela:   "log_bairro",
+			Operacao: immu.DELTA_INSERT,
+			Chave:    map[string]any{"bai_nu": "12"},
+			Depois: map[string]any{
+				"bai_nu": "12", "ufe_sg": "PR", "loc_nu": "1", "bai_no": "Zona 2", "bai_no_abrev": "Zona 2",
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("alterações esperadas:\n%+v\nobtidas:\n%+v", want, changes)
+	}
+
+	wantSummary := Summary{Table: "log_bairro", Inseridos: 1, Removidos: 1, Alterados: 1, Colunas: map[string]int{"bai_no": 1}}
+	if !reflect.DeepEqual(summary, wantSummary) {
+		t.Errorf("resumo esperado %+v, obtido %+v", wantSummary, summary)
+	}
+}
+
+func TestSourceRowsSortsByKey(t *testing.T) {
+	sortChunkSize = 2
+	t.Cleanup(func() { sortChunkSize = immu.DIFF_SORT_CHUNK_SIZE })
+
+	table, _ := types.TableByName("log_bairro")
+	source := fstest.MapFS{
+		"LOG_BAIRRO.TXT": {Data: []byte(
+			"100@PR@1@Zona 1@\n" +
+				"9@PR@1@Zona 9@\n" +
+				"21@PR@1@Zona 21@\n" +
+				"10@PR@1@Zona 10@\n" +
+				"2@PR@1@Zona 2@\n")},
+	}
+
+	var keys []any
+	err := SourceRows(source)(context.Background(), table, func(row []any) error {
+		keys = append(keys, row[0])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bai_nu é numérica: 9 vem antes de 10 e 100.
+	if want := []any{"2", "9", "10", "21", "100"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("chaves esperadas %v, obtidas %v", want, keys)
+	}
+}
+
+func TestTableRejectsUnorderedSource(t *testing.T) {
+	table, _ := types.TableByName("log_faixa_uf")
+	rows := func(keys ...string) RowSource {
+		return func(ctx context.Context, table types.Table, handle func(row []any) error) error {
+			for _, key := range keys {
+				if err := handle([]any{key, "80000000", "87999999"}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	_, err := Table(context.Background(), table, rows("PR", "SC"), rows("SC", "PR"), func(Change) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "fora de ordem") {
+		t.Errorf("esperado erro de ordem, obtido %v", err)
+	}
+
+	_, err = Table(context.Background(), table, rows("PR", "PR"), rows("PR"), func(Change) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "repetida") {
+		t.Errorf("esperado erro de chave repetida, obtido %v", err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	table, _ := types.TableByName("log_faixa_uf")
+
+	row, err := normalize(table, []any{" PR ", "80000000", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []any{"PR", "80000000", nil}; !reflect.DeepEqual(row, want) {
+		t.Errorf("linha esperada %#v, obtida %#v", want, row)
+	}
+
+	if _, err := normalize(table, []any{"PR", "80000000"}); err == nil {
+		t.Error("esperado erro para linha com colunas faltando")
+	}
+}
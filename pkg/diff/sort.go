@@ -0,0 +1,238 @@
+package diff
+
+import (
+	"bufio"
+	"cmp"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	immu "github.com/diegodario88/importador-cep-correios/pkg/constants"
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+)
+
+var sortChunkSize = immu.DIFF_SORT_CHUNK_SIZE
+
+// Ordem da chave primária usada no diff: colunas numéricas pelo valor inteiro
+// e texto byte a byte, a mesma de Storage.StreamTable nos dois bancos.
+func compareKeys(table types.Table, keys []int, a, b []any) int {
+	for _, index := range keys {
+		if order := compareValues(table.Columns[index].Numeric, a[index], b[index]); order != 0 {
+			return order
+		}
+	}
+	return 0
+}
+
+func compareValues(numeric bool, a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	left, right := a.(string), b.(string)
+	if numeric {
+		x, errX := strconv.ParseInt(left, 10, 64)
+		y, errY := strconv.ParseInt(right, 10, 64)
+		if errX == nil && errY == nil {
+			return cmp.Compare(x, y)
+		}
+	}
+	return strings.Compare(left, right)
+}
+
+// Ordena as linhas de uma tabela sem mantê-la inteira em memória: a cada
+// sortChunkSize linhas o bloco é ordenado e gravado em um arquivo temporário,
+// e os blocos são intercalados na entrega.
+type sorter struct {
+	table types.Table
+	keys  []int
+	chunk [][]any
+	dir   string
+	runs  []string
+}
+
+func newSorter(table types.Table, keys []int) *sorter {
+	return &sorter{table: table, keys: keys}
+}
+
+func (s *sorter) add(row []any) error {
+	normalized, err := normalize(s.table, row)
+	if err != nil {
+		return err
+	}
+
+	s.chunk = append(s.chunk, normalized)
+	if len(s.chunk) >= sortChunkSize {
+		return s.spill()
+	}
+	return nil
+}
+
+func (s *sorter) sortChunk() {
+	slices.SortStableFunc(s.chunk, func(a, b []any) int {
+		return compareKeys(s.table, s.keys, a, b)
+	})
+}
+
+func (s *sorter) spill() error {
+	s.sortChunk()
+
+	if s.dir == "" {
+		dir, err := os.MkdirTemp("", "importador-diff-*")
+		if err != nil {
+			return fmt.Errorf("erro ao criar diretório temporário: %w", err)
+		}
+		s.dir = dir
+	}
+
+	runPath := filepath.Join(s.dir, fmt.Sprintf("%s_%d.jsonl", s.table.Name, len(s.runs)))
+	file, err := os.Create(runPath)
+	if err != nil {
+		return fmt.Errorf("erro ao criar %s: %w", runPath, err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, row := range s.chunk {
+		if err := encoder.Encode(row); err != nil {
+			return fmt.Errorf("erro ao gravar %s: %w", runPath, err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("erro ao gravar %s: %w", runPath, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("erro ao gravar %s: %w", runPath, err)
+	}
+
+	s.runs = append(s.runs, runPath)
+	s.chunk = nil
+	return nil
+}
+
+// Entrega as linhas em ordem: direto da memória quando coube tudo em um
+// bloco, senão intercalando os arquivos temporários.
+func (s *sorter) emit(ctx context.Context, handle func(row []any) error) error {
+	if len(s.runs) == 0 {
+		s.sortChunk()
+		for _, row := range s.chunk {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if err := handle(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(s.chunk) > 0 {
+		if err := s.spill(); err != nil {
+			return err
+		}
+	}
+
+	pending := &runHeap{less: func(a, b []any) bool {
+		return compareKeys(s.table, s.keys, a, b) < 0
+	}}
+
+	for _, runPath := range s.runs {
+		file, err := os.Open(runPath)
+		if err != nil {
+			return fmt.Errorf("erro ao abrir %s: %w", runPath, err)
+		}
+		defer file.Close()
+
+		current := &run{path: runPath, decoder: json.NewDecoder(bufio.NewReader(file))}
+		ok, err := current.advance()
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			pending.runs = append(pending.runs, current)
+		}
+	}
+	heap.Init(pending)
+
+	for pending.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		current := pending.runs[0]
+		if err := handle(current.row); err != nil {
+			return err
+		}
+
+		ok, err := current.advance()
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			heap.Fix(pending, 0)
+		} else {
+			heap.Pop(pending)
+		}
+	}
+	return nil
+}
+
+func (s *sorter) close() {
+	if s.dir != "" {
+		os.RemoveAll(s.dir)
+	}
+}
+
+type run struct {
+	path    string
+	decoder *json.Decoder
+	row     []any
+}
+
+func (r *run) advance() (bool, error) {
+	var row []any
+	if err := r.decoder.Decode(&row); err != nil {
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		return false, fmt.Errorf("erro ao ler %s: %w", r.path, err)
+	}
+
+	r.row = row
+	return true, nil
+}
+
+type runHeap struct {
+	runs []*run
+	less func(a, b []any) bool
+}
+
+func (h *runHeap) Len() int           { return len(h.runs) }
+func (h *runHeap) Less(i, j int) bool { return h.less(h.runs[i].row, h.runs[j].row) }
+func (h *runHeap) Swap(i, j int)      { h.runs[i], h.runs[j] = h.runs[j], h.runs[i] }
+func (h *runHeap) Push(x any)         { h.runs = append(h.runs, x.(*run)) }
+
+func (h *runHeap) Pop() any {
+	last := h.runs[len(h.runs)-1]
+	h.runs = h.runs[:len(h.runs)-1]
+	return last
+}
@@ -0,0 +1,106 @@
+package dump
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// O pg_dump com --schema não inclui as extensões, que ficam no schema public.
+// A função normaliza e os índices trigram dependem delas, então são criadas
+// antes do pg_restore. A flag --extension do pg_dump só existe a partir do
+// PostgreSQL 14.
+const preRestoreSql = `CREATE EXTENSION IF NOT EXISTS unaccent WITH SCHEMA public;
+CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA public;
+`
+
+// Executa o pg_dump no formato custom; substituído nos testes.
+var pgDump = func(connString string, schema string, dumpPath string) error {
+	cmd := exec.Command("pg_dump",
+		"--format=custom",
+		"--no-owner",
+		"--schema="+schema,
+		"--file="+dumpPath,
+		"--dbname="+connString,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Gera o dump do schema e o script de pré-restauração com as extensões,
+// retornando o caminho de ambos.
+func Export(connString string, schema string, versao string, outputDir string) (string, string, error) {
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return "", "", fmt.Errorf("erro ao criar diretório %s: %w", outputDir, err)
+	}
+
+	baseName, err := artifactName(outputDir, schema, versao, time.Now())
+	if err != nil {
+		return "", "", err
+	}
+	dumpPath := filepath.Join(outputDir, baseName+".dump")
+	preRestorePath := filepath.Join(outputDir, baseName+".pre-restore.sql")
+
+	if err := pgDump(connString, schema, dumpPath); err != nil {
+		return "", "", fmt.Errorf("erro ao executar pg_dump do schema %s: %w", schema, err)
+	}
+
+	if err := os.WriteFile(preRestorePath, []byte(preRestoreSql), 0o644); err != nil {
+		return "", "", fmt.Errorf("erro ao gravar script de pré-restauração %s: %w", preRestorePath, err)
+	}
+
+	var checksumLines string
+	for _, path := range []string{dumpPath, preRestorePath} {
+		checksum, err := sha256File(path)
+		if err != nil {
+			return "", "", err
+		}
+		checksumLines += fmt.Sprintf("%s  %s\n", checksum, filepath.Base(path))
+	}
+
+	if err := os.WriteFile(dumpPath+".sha256", []byte(checksumLines), 0o644); err != nil {
+		return "", "", fmt.Errorf("erro ao gravar checksum de %s: %w", dumpPath, err)
+	}
+
+	return dumpPath, preRestorePath, nil
+}
+
+// Um dump anterior com o mesmo nome (ex: reimportação com -force ou versão
+// desconhecida) não é sobrescrito: o novo recebe a data/hora UTC no nome.
+func artifactName(outputDir string, schema string, versao string, now time.Time) (string, error) {
+	baseName := fmt.Sprintf("%s_%s", schema, versao)
+	for _, name := range []string{baseName, baseName + "_" + now.UTC().Format("20060102T150405Z")} {
+		_, err := os.Stat(filepath.Join(outputDir, name+".dump"))
+		if errors.Is(err, fs.ErrNotExist) {
+			return name, nil
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("erro ao verificar dump %s: %w", name, err)
+		}
+	}
+	return "", fmt.Errorf("dump %s já existe em %s", baseName, outputDir)
+}
+
+func sha256File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("erro ao abrir arquivo %s: %w", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("erro ao calcular checksum de %s: %w", path, err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
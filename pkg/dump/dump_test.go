@@ -0,0 +1,102 @@
+package dump
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func fakePgDump(t *testing.T, content string) {
+	t.Helper()
+
+	original := pgDump
+	t.Cleanup(func() { pgDump = original })
+
+	pgDump = func(connString string, schema string, dumpPath string) error {
+		return os.WriteFile(dumpPath, []byte(content), 0o644)
+	}
+}
+
+func checksumLine(t *testing.T, path string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256(content)
+	return fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), filepath.Base(path))
+}
+
+func TestExport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "dump")
+	fakePgDump(t, "PGDMP conteúdo")
+
+	dumpPath, preRestorePath, err := Export("postgres://", "correios", "25041", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := filepath.Join(dir, "correios_25041.dump"); dumpPath != want {
+		t.Errorf("dump esperado %s, obtido %s", want, dumpPath)
+	}
+
+	if want := filepath.Join(dir, "correios_25041.pre-restore.sql"); preRestorePath != want {
+		t.Errorf("script esperado %s, obtido %s", want, preRestorePath)
+	}
+
+	script, err := os.ReadFile(preRestorePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, extension := range []string{"unaccent", "pg_trgm"} {
+		pattern := regexp.MustCompile(`(?m)^CREATE EXTENSION IF NOT EXISTS ` + extension + ` WITH SCHEMA public;$`)
+		if !pattern.Match(script) {
+			t.Errorf("script sem CREATE EXTENSION %s:\n%s", extension, script)
+		}
+	}
+
+	sidecar, err := os.ReadFile(dumpPath + ".sha256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := checksumLine(t, dumpPath) + checksumLine(t, preRestorePath); string(sidecar) != want {
+		t.Errorf("checksum esperado:\n%s\nobtido:\n%s", want, sidecar)
+	}
+}
+
+func TestExportDoesNotOverwrite(t *testing.T) {
+	dir := t.TempDir()
+
+	fakePgDump(t, "primeiro")
+	first, _, err := Export("postgres://", "correios", "desconhecida", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fakePgDump(t, "segundo")
+	second, _, err := Export("postgres://", "correios", "desconhecida", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !regexp.MustCompile(`^correios_desconhecida_\d{8}T\d{6}Z\.dump$`).MatchString(filepath.Base(second)) {
+		t.Errorf("esperado nome com data/hora para o segundo dump, obtido %s", second)
+	}
+
+	content, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "primeiro" {
+		t.Errorf("dump anterior sobrescrito: %q", content)
+	}
+}
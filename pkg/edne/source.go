@@ -0,0 +1,207 @@
+package edne
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const localidadeFile = "LOG_LOCALIDADE.TXT"
+
+type Source struct {
+	FS       fs.FS
+	Path     string
+	root     fs.FS
+	archives []string
+	closers  []io.Closer
+}
+
+func Open(sourcePath string) (*Source, error) {
+	absPath, err := filepath.Abs(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao resolver caminho %s: %w", sourcePath, err)
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("origem %s não encontrada: %w", absPath, err)
+	}
+
+	if info.IsDir() {
+		return &Source{
+			FS:   os.DirFS(absPath),
+			Path: absPath,
+			// A versão é buscada apenas dentro da origem: outras distribuições
+			// no diretório pai não podem definir a versão da importação.
+			root: os.DirFS(absPath),
+		}, nil
+	}
+
+	if !strings.EqualFold(filepath.Ext(absPath), ".zip") {
+		return nil, fmt.Errorf("origem %s deve ser um diretório ou um arquivo .zip", absPath)
+	}
+
+	file, err := os.Open(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo %s: %w", absPath, err)
+	}
+
+	archive, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("erro ao abrir arquivo %s: %w", absPath, err)
+	}
+
+	src := &Source{
+		Path:    absPath,
+		root:    archive,
+		closers: []io.Closer{file},
+	}
+
+	fsys, err := src.locate(archive, file)
+	if err != nil {
+		src.Close()
+		return nil, err
+	}
+
+	src.FS = fsys
+	return src, nil
+}
+
+func (s *Source) Close() error {
+	var errs []error
+	for _, closer := range s.closers {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("erro ao fechar origem %s: %v", s.Path, errs)
+	}
+	return nil
+}
+
+// O conteúdo do zip é lido sob demanda através de data, o io.ReaderAt de onde
+// archive foi aberto.
+func (s *Source) locate(archive *zip.Reader, data io.ReaderAt) (fs.FS, error) {
+	var candidates []string
+	var nested []*zip.File
+
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		base := path.Base(file.Name)
+		switch {
+		case strings.HasSuffix(strings.ToUpper(base), localidadeFile):
+			candidates = append(candidates, path.Dir(file.Name))
+		case strings.EqualFold(path.Ext(base), ".zip"):
+			nested = append(nested, file)
+		}
+	}
+
+	if len(candidates) > 0 {
+		return fs.Sub(archive, preferredDir(candidates))
+	}
+
+	for _, file := range nested {
+		inner, innerData, closer, err := openNested(file, data)
+		if err != nil {
+			return nil, err
+		}
+
+		fsys, err := s.locate(inner, innerData)
+		if err != nil {
+			if closer != nil {
+				closer.Close()
+			}
+			continue
+		}
+
+		if closer != nil {
+			s.closers = append(s.closers, closer)
+		}
+		s.archives = append(s.archives, path.Base(file.Name))
+		return fsys, nil
+	}
+
+	return nil, fmt.Errorf("arquivo %s não contém a base eDNE (%s não encontrado)", s.Path, localidadeFile)
+}
+
+func preferredDir(candidates []string) string {
+	for _, dir := range candidates {
+		if strings.Contains(strings.ToLower(dir), "delimitado") {
+			return dir
+		}
+	}
+	return candidates[0]
+}
+
+// Um zip interno armazenado sem compressão é lido direto da sua posição no zip
+// externo; comprimido, é descompactado em um arquivo temporário, removido ao
+// fechar a origem.
+func openNested(file *zip.File, data io.ReaderAt) (*zip.Reader, io.ReaderAt, io.Closer, error) {
+	if file.Method == zip.Store {
+		offset, err := file.DataOffset()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("erro ao abrir arquivo interno %s: %w", file.Name, err)
+		}
+
+		section := io.NewSectionReader(data, offset, int64(file.CompressedSize64))
+		inner, err := zip.NewReader(section, section.Size())
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("erro ao abrir arquivo interno %s: %w", file.Name, err)
+		}
+		return inner, section, nil, nil
+	}
+
+	temp, err := spill(file)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	inner, err := zip.NewReader(temp.File, int64(file.UncompressedSize64))
+	if err != nil {
+		temp.Close()
+		return nil, nil, nil, fmt.Errorf("erro ao abrir arquivo interno %s: %w", file.Name, err)
+	}
+	return inner, temp.File, temp, nil
+}
+
+type tempFile struct {
+	*os.File
+}
+
+func (t tempFile) Close() error {
+	err := t.File.Close()
+	if removeErr := os.Remove(t.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+func spill(file *zip.File) (tempFile, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return tempFile{}, fmt.Errorf("erro ao abrir arquivo interno %s: %w", file.Name, err)
+	}
+	defer reader.Close()
+
+	temp, err := os.CreateTemp("", "edne-*.zip")
+	if err != nil {
+		return tempFile{}, fmt.Errorf("erro ao criar arquivo temporário para %s: %w", file.Name, err)
+	}
+
+	if _, err := io.Copy(temp, reader); err != nil {
+		tempFile{temp}.Close()
+		return tempFile{}, fmt.Errorf("erro ao extrair arquivo interno %s: %w", file.Name, err)
+	}
+	return tempFile{temp}, nil
+}
@@ -0,0 +1,116 @@
+package edne
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func zipBytes(t *testing.T, method uint16, files map[string][]byte) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for name, content := range files {
+		entry, err := writer.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := entry.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestOpenNestedZip(t *testing.T) {
+	localidade := []byte("1@PR@Maringá\n")
+
+	tests := []struct {
+		name   string
+		method uint16
+	}{
+		{name: "zip interno armazenado", method: zip.Store},
+		{name: "zip interno comprimido", method: zip.Deflate},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inner := zipBytes(t, zip.Deflate, map[string][]byte{"Delimitado/" + localidadeFile: localidade})
+			outer := zipBytes(t, test.method, map[string][]byte{
+				"Leiame.pdf":            []byte("%PDF"),
+				"eDNE_Basico_25041.zip": inner,
+			})
+
+			dir := t.TempDir()
+			source := filepath.Join(dir, "correios.zip")
+			if err := os.WriteFile(source, outer, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Setenv("TMPDIR", dir)
+			src, err := Open(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := fs.ReadFile(src.FS, localidadeFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(content, localidade) {
+				t.Errorf("conteúdo esperado %q, obtido %q", localidade, content)
+			}
+
+			if len(src.archives) != 1 || src.archives[0] != "eDNE_Basico_25041.zip" {
+				t.Errorf("zips internos esperados [eDNE_Basico_25041.zip], obtidos %v", src.archives)
+			}
+
+			if err := src.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			temps, err := filepath.Glob(filepath.Join(dir, "edne-*.zip"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(temps) > 0 {
+				t.Errorf("arquivos temporários não removidos: %v", temps)
+			}
+		})
+	}
+}
+
+func TestOpenSkipsNestedZipWithoutBase(t *testing.T) {
+	other := zipBytes(t, zip.Deflate, map[string][]byte{"manual.txt": []byte("manual")})
+	inner := zipBytes(t, zip.Deflate, map[string][]byte{localidadeFile: []byte("1@PR@Maringá\n")})
+	outer := zipBytes(t, zip.Store, map[string][]byte{
+		"a_manual.zip":          other,
+		"eDNE_Basico_25041.zip": inner,
+	})
+
+	source := filepath.Join(t.TempDir(), "correios.zip")
+	if err := os.WriteFile(source, outer, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := Open(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	if len(src.archives) != 1 || src.archives[0] != "eDNE_Basico_25041.zip" {
+		t.Errorf("zips internos esperados [eDNE_Basico_25041.zip], obtidos %v", src.archives)
+	}
+}
@@ -0,0 +1,104 @@
+package edne
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+const leiameMaxDepth = 2
+
+var ErrVersionNotFound = errors.New("não foi possível detectar a versão da base eDNE")
+
+var (
+	distributionPattern = regexp.MustCompile(`(?i)edne_(?:basico|delta)_(\d{5})`)
+	leiamePattern       = regexp.MustCompile(`(?i)vers[aã]o[^0-9\n]{0,30}(\d{5})`)
+)
+
+func DetectVersion(src *Source) (string, error) {
+	if match := distributionPattern.FindStringSubmatch(src.Path); match != nil {
+		return match[1], nil
+	}
+
+	for _, name := range src.archives {
+		if match := distributionPattern.FindStringSubmatch(name); match != nil {
+			return match[1], nil
+		}
+	}
+
+	var names, leiames []string
+	err := fs.WalkDir(src.root, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if entry.IsDir() {
+			if name != "." && strings.Count(name, "/") >= leiameMaxDepth-1 {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		names = append(names, name)
+		if isLeiame(path.Base(name)) {
+			leiames = append(leiames, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("erro ao percorrer origem %s: %w", src.Path, err)
+	}
+
+	for _, name := range names {
+		if match := distributionPattern.FindStringSubmatch(name); match != nil {
+			return match[1], nil
+		}
+	}
+
+	for _, name := range leiames {
+		version, err := versionFromLeiame(src.root, name)
+		if err != nil {
+			return "", err
+		}
+
+		if version != "" {
+			return version, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w em %s", ErrVersionNotFound, src.Path)
+}
+
+func isLeiame(name string) bool {
+	upper := strings.ToUpper(name)
+	return strings.HasPrefix(upper, "LEIAME") || strings.HasPrefix(upper, "LEIA-ME") || strings.HasPrefix(upper, "LEIA_ME")
+}
+
+func versionFromLeiame(fsys fs.FS, name string) (string, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return "", fmt.Errorf("erro ao abrir arquivo %s: %w", name, err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(charmap.ISO8859_1.NewDecoder().Reader(file))
+	if err != nil {
+		return "", fmt.Errorf("erro ao ler arquivo %s: %w", name, err)
+	}
+
+	if match := distributionPattern.FindSubmatch(content); match != nil {
+		return string(match[1]), nil
+	}
+
+	if match := leiamePattern.FindSubmatch(content); match != nil {
+		return string(match[1]), nil
+	}
+
+	return "", nil
+}
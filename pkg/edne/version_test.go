@@ -0,0 +1,170 @@
+package edne
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		filePath := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func writeZip(t *testing.T, zipPath string, files map[string]string) {
+	t.Helper()
+
+	file, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for name, content := range files {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := entry.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectVersion(t *testing.T) {
+	base := map[string]string{localidadeFile: "1@PR@Maringá\n"}
+
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, dir string) string
+		version string
+		err     error
+	}{
+		{
+			name: "diretório com o nome da distribuição",
+			setup: func(t *testing.T, dir string) string {
+				source := filepath.Join(dir, "eDNE_Basico_25041")
+				writeFiles(t, source, base)
+				return source
+			},
+			version: "25041",
+		},
+		{
+			name: "diretório dentro da distribuição",
+			setup: func(t *testing.T, dir string) string {
+				source := filepath.Join(dir, "eDNE_Delta_25051", "delta")
+				writeFiles(t, source, base)
+				return source
+			},
+			version: "25051",
+		},
+		{
+			name: "arquivo zip com o nome da distribuição",
+			setup: func(t *testing.T, dir string) string {
+				source := filepath.Join(dir, "eDNE_Basico_24121.zip")
+				writeZip(t, source, map[string]string{"Delimitado/" + localidadeFile: base[localidadeFile]})
+				return source
+			},
+			version: "24121",
+		},
+		{
+			name: "zip interno com o nome da distribuição",
+			setup: func(t *testing.T, dir string) string {
+				inner := filepath.Join(dir, "eDNE_Basico_24111.zip")
+				writeZip(t, inner, map[string]string{localidadeFile: base[localidadeFile]})
+
+				content, err := os.ReadFile(inner)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				source := filepath.Join(dir, "correios.zip")
+				writeZip(t, source, map[string]string{"eDNE_Basico_24111.zip": string(content)})
+				return source
+			},
+			version: "24111",
+		},
+		{
+			name: "LEIAME junto da base",
+			setup: func(t *testing.T, dir string) string {
+				source := filepath.Join(dir, "eDNE", "basico")
+				writeFiles(t, source, map[string]string{
+					localidadeFile: base[localidadeFile],
+					// O LEIAME é distribuído em ISO-8859-1.
+					"LEIAME.TXT": "Base eDNE - Vers\xe3o: 25061\n",
+				})
+				return source
+			},
+			version: "25061",
+		},
+		{
+			name: "LEIAME dentro do zip",
+			setup: func(t *testing.T, dir string) string {
+				source := filepath.Join(dir, "correios.zip")
+				writeZip(t, source, map[string]string{
+					"Leia-me.txt":                  "Versao da base 25071",
+					"Delimitado/" + localidadeFile: base[localidadeFile],
+				})
+				return source
+			},
+			version: "25071",
+		},
+		{
+			name: "distribuição e LEIAME no diretório pai são ignorados",
+			setup: func(t *testing.T, dir string) string {
+				writeFiles(t, dir, map[string]string{"eDNE/LEIAME.TXT": "Versao da base 24121"})
+				writeZip(t, filepath.Join(dir, "eDNE", "eDNE_Basico_24121.zip"), base)
+				source := filepath.Join(dir, "eDNE", "delta")
+				writeFiles(t, source, base)
+				return source
+			},
+			err: ErrVersionNotFound,
+		},
+		{
+			name: "base sem indicação de versão",
+			setup: func(t *testing.T, dir string) string {
+				source := filepath.Join(dir, "eDNE", "basico")
+				writeFiles(t, source, base)
+				return source
+			},
+			err: ErrVersionNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src, err := Open(test.setup(t, t.TempDir()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer src.Close()
+
+			version, err := DetectVersion(src)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("erro esperado %v, obtido %v", test.err, err)
+			}
+
+			if version != test.version {
+				t.Errorf("versão esperada %q, obtida %q", test.version, version)
+			}
+		})
+	}
+}
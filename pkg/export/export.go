@@ -0,0 +1,90 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+)
+
+type FileReport struct {
+	File  string
+	Lines int
+}
+
+// Exporta a tabela lendo as linhas do banco uma a uma. Com partitionUF,
+// gera um arquivo por UF (como os LOG_LOGRADOURO_XX.TXT) para as tabelas
+// que possuem coluna de UF.
+func Table(ctx context.Context, storage types.Storage, table types.Table, format string, outputDir string, partitionUF bool) ([]FileReport, error) {
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório %s: %w", outputDir, err)
+	}
+
+	columns := table.ColumnNames()
+	ufIndex := -1
+	if partitionUF {
+		ufIndex = slices.IndexFunc(columns, func(column string) bool {
+			return column == "uf" || column == "ufe_sg"
+		})
+	}
+
+	writers := make(map[string]Writer)
+	lines := make(map[string]int)
+
+	closeAll := func() error {
+		var firstErr error
+		for _, writer := range writers {
+			if err := writer.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+
+	err := storage.StreamTable(ctx, table, func(row []any) error {
+		path := filepath.Join(outputDir, fmt.Sprintf("%s.%s", table.Name, format))
+		if ufIndex >= 0 {
+			uf, _ := row[ufIndex].(string)
+			if uf == "" {
+				uf = "SEM_UF"
+			}
+			path = filepath.Join(outputDir, fmt.Sprintf("%s_%s.%s", table.Name, uf, format))
+		}
+
+		writer, ok := writers[path]
+		if !ok {
+			created, err := NewWriter(format, path, columns)
+			if err != nil {
+				return err
+			}
+			writer = created
+			writers[path] = writer
+		}
+
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("erro ao escrever %s: %w", path, err)
+		}
+		lines[path]++
+		return nil
+	})
+
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+
+	if err := closeAll(); err != nil {
+		return nil, fmt.Errorf("erro ao finalizar exportação de %s: %w", table.Name, err)
+	}
+
+	var reports []FileReport
+	for path, count := range lines {
+		reports = append(reports, FileReport{File: path, Lines: count})
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].File < reports[j].File })
+	return reports, nil
+}
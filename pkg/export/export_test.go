@@ -0,0 +1,224 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+	"github.com/parquet-go/parquet-go"
+)
+
+type fakeStorage struct {
+	types.Storage
+	rows [][]any
+}
+
+func (s fakeStorage) StreamTable(ctx context.Context, table types.Table, handle func(row []any) error) error {
+	for _, row := range s.rows {
+		if err := handle(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var bairros = [][]any{
+	{"10", "PR", "1", "Centro", "Centro"},
+	{"11", "PR", "1", "Zona 7", nil},
+	{"20", "SP", "2", "Sé", "Sé"},
+}
+
+// Lê o arquivo exportado e devolve o cabeçalho (nomes das colunas) e as
+// linhas indexadas pelo nome da coluna.
+func readExported(t *testing.T, format string, path string) ([]string, []map[string]any) {
+	t.Helper()
+
+	switch format {
+	case CSV:
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		records, err := csv.NewReader(file).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var rows []map[string]any
+		for _, record := range records[1:] {
+			row := make(map[string]any)
+			for i, column := range records[0] {
+				row[column] = record[i]
+			}
+			rows = append(rows, row)
+		}
+		return records[0], rows
+
+	case JSONL:
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var header []string
+		var rows []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			row := make(map[string]any)
+			if err := json.Unmarshal([]byte(line), &row); err != nil {
+				t.Fatal(err)
+			}
+			rows = append(rows, row)
+
+			if header == nil {
+				for column := range row {
+					header = append(header, column)
+				}
+			}
+		}
+		return header, rows
+
+	case PARQUET:
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parquetFile, err := parquet.OpenFile(file, info.Size())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var header []string
+		for _, field := range parquetFile.Schema().Fields() {
+			header = append(header, field.Name())
+		}
+
+		reader := parquet.NewReader(parquetFile)
+		defer reader.Close()
+
+		read := make([]parquet.Row, parquetFile.NumRows())
+		if n, err := reader.ReadRows(read); n != len(read) {
+			t.Fatalf("lidas %d de %d linhas: %v", n, len(read), err)
+		}
+
+		var rows []map[string]any
+		for _, values := range read {
+			row := make(map[string]any)
+			for _, value := range values {
+				if value.IsNull() {
+					row[header[value.Column()]] = nil
+				} else {
+					row[header[value.Column()]] = value.String()
+				}
+			}
+			rows = append(rows, row)
+		}
+		return header, rows
+	}
+
+	t.Fatalf("formato desconhecido %s", format)
+	return nil, nil
+}
+
+func TestTable(t *testing.T) {
+	table, _ := types.TableByName("log_bairro")
+	columns := table.ColumnNames()
+
+	tests := []struct {
+		format      string
+		partitionUF bool
+		files       map[string]int
+	}{
+		{format: CSV, files: map[string]int{"log_bairro.csv": 3}},
+		{format: JSONL, files: map[string]int{"log_bairro.jsonl": 3}},
+		{format: PARQUET, files: map[string]int{"log_bairro.parquet": 3}},
+		{format: CSV, partitionUF: true, files: map[string]int{"log_bairro_PR.csv": 2, "log_bairro_SP.csv": 1}},
+		{format: JSONL, partitionUF: true, files: map[string]int{"log_bairro_PR.jsonl": 2, "log_bairro_SP.jsonl": 1}},
+		{format: PARQUET, partitionUF: true, files: map[string]int{"log_bairro_PR.parquet": 2, "log_bairro_SP.parquet": 1}},
+	}
+
+	for _, test := range tests {
+		name := test.format
+		if test.partitionUF {
+			name += " por UF"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			reports, err := Table(context.Background(), fakeStorage{rows: bairros}, table, test.format, dir, test.partitionUF)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(reports) != len(test.files) {
+				t.Fatalf("arquivos esperados %v, obtidos %v", test.files, reports)
+			}
+
+			exported := make(map[string]map[string]any)
+			for _, report := range reports {
+				file := filepath.Base(report.File)
+				if report.Lines != test.files[file] {
+					t.Errorf("%s: linhas esperadas %d, relatadas %d", file, test.files[file], report.Lines)
+				}
+
+				header, rows := readExported(t, test.format, report.File)
+				sorted, want := slices.Clone(header), slices.Clone(columns)
+				slices.Sort(sorted)
+				slices.Sort(want)
+				if !slices.Equal(sorted, want) {
+					t.Errorf("%s: colunas esperadas %v, obtidas %v", file, columns, header)
+				}
+
+				if test.format == CSV && !slices.Equal(header, columns) {
+					t.Errorf("%s: cabeçalho esperado %v, obtido %v", file, columns, header)
+				}
+
+				if len(rows) != test.files[file] {
+					t.Errorf("%s: linhas esperadas %d, lidas %d", file, test.files[file], len(rows))
+				}
+
+				for _, row := range rows {
+					exported[row["bai_nu"].(string)] = row
+				}
+			}
+
+			if got := exported["20"]["bai_no"]; got != "Sé" {
+				t.Errorf("bai_no esperado %q, obtido %v", "Sé", got)
+			}
+
+			// O CSV não distingue nulo de vazio.
+			want := any(nil)
+			if test.format == CSV {
+				want = ""
+			}
+			if got := exported["11"]["bai_no_abrev"]; got != want {
+				t.Errorf("bai_no_abrev esperado %#v, obtido %#v", want, got)
+			}
+		})
+	}
+}
+
+func TestNewWriterUnknownFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log_bairro.xml")
+	if _, err := NewWriter("xml", path, []string{"bai_nu"}); err == nil {
+		t.Fatal("esperado erro para formato desconhecido")
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("arquivo do formato desconhecido não removido: %v", err)
+	}
+}
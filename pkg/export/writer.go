@@ -0,0 +1,150 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+const (
+	JSONL   = "jsonl"
+	CSV     = "csv"
+	PARQUET = "parquet"
+)
+
+type Writer interface {
+	Write(row []any) error
+	Close() error
+}
+
+func NewWriter(format string, path string, columns []string) (Writer, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo %s: %w", path, err)
+	}
+
+	switch format {
+	case JSONL:
+		return newJSONLWriter(file, columns), nil
+	case CSV:
+		return newCSVWriter(file, columns)
+	case PARQUET:
+		return newParquetWriter(file, columns), nil
+	default:
+		file.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("formato de exportação desconhecido: %s", format)
+	}
+}
+
+type jsonlWriter struct {
+	file    *os.File
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+	columns []string
+}
+
+func newJSONLWriter(file *os.File, columns []string) *jsonlWriter {
+	buffer := bufio.NewWriter(file)
+	return &jsonlWriter{file: file, buffer: buffer, encoder: json.NewEncoder(buffer), columns: columns}
+}
+
+func (w *jsonlWriter) Write(row []any) error {
+	record := make(map[string]any, len(w.columns))
+	for i, column := range w.columns {
+		record[column] = row[i]
+	}
+	return w.encoder.Encode(record)
+}
+
+func (w *jsonlWriter) Close() error {
+	if err := w.buffer.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+type csvWriter struct {
+	file   *os.File
+	writer *csv.Writer
+	record []string
+}
+
+func newCSVWriter(file *os.File, columns []string) (*csvWriter, error) {
+	writer := csv.NewWriter(file)
+	if err := writer.Write(columns); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &csvWriter{file: file, writer: writer, record: make([]string, len(columns))}, nil
+}
+
+func (w *csvWriter) Write(row []any) error {
+	for i, value := range row {
+		w.record[i], _ = value.(string)
+	}
+	return w.writer.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// Todas as colunas são exportadas como texto opcional, como no eDNE; o
+// parquet ordena as colunas pelo nome, então cada valor é posicionado pelo
+// índice da coluna no schema.
+type parquetWriter struct {
+	file    *os.File
+	writer  *parquet.Writer
+	indexes []int
+}
+
+func newParquetWriter(file *os.File, columns []string) *parquetWriter {
+	group := parquet.Group{}
+	for _, column := range columns {
+		group[column] = parquet.Optional(parquet.String())
+	}
+
+	schema := parquet.NewSchema("correios", group)
+	indexes := make([]int, len(columns))
+	for i, column := range columns {
+		leaf, _ := schema.Lookup(column)
+		indexes[i] = leaf.ColumnIndex
+	}
+
+	writer := parquet.NewWriter(file, schema, parquet.Compression(&parquet.Zstd))
+	return &parquetWriter{file: file, writer: writer, indexes: indexes}
+}
+
+func (w *parquetWriter) Write(row []any) error {
+	values := make(parquet.Row, len(row))
+	for i, value := range row {
+		index := w.indexes[i]
+		if text, ok := value.(string); ok {
+			values[index] = parquet.ByteArrayValue([]byte(text)).Level(0, 1, index)
+		} else {
+			values[index] = parquet.NullValue().Level(0, 0, index)
+		}
+	}
+
+	_, err := w.writer.WriteRows([]parquet.Row{values})
+	return err
+}
+
+func (w *parquetWriter) Close() error {
+	if err := w.writer.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
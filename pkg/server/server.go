@@ -0,0 +1,219 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	immu "github.com/diegodario88/importador-cep-correios/pkg/constants"
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+	"github.com/diegodario88/importador-cep-correios/pkg/utils"
+)
+
+type Server struct {
+	storage types.Storage
+}
+
+type errorResponse struct {
+	Erro string `json:"erro"`
+}
+
+func New(storage types.Storage) *Server {
+	return &Server{storage: storage}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /cep/{cep}", s.getCep)
+	mux.HandleFunc("GET /busca", s.searchCep)
+	mux.HandleFunc("GET /endereco", s.getCepNumero)
+	return mux
+}
+
+// Atende até o ctx ser cancelado; as requisições em andamento têm até
+// SERVER_SHUTDOWN_TIMEOUT para terminar antes do encerramento.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	// Garante que a goroutine de encerramento termine mesmo quando o servidor
+	// falha ao iniciar (ex: porta em uso).
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: immu.SERVER_READ_TIMEOUT,
+		ReadTimeout:       immu.SERVER_READ_TIMEOUT,
+		WriteTimeout:      immu.SERVER_WRITE_TIMEOUT,
+		IdleTimeout:       immu.SERVER_IDLE_TIMEOUT,
+	}
+
+	shutdown := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), immu.SERVER_SHUTDOWN_TIMEOUT)
+		defer cancel()
+		shutdown <- srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	if err := <-shutdown; err != nil {
+		return fmt.Errorf("erro ao encerrar servidor: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) getCep(w http.ResponseWriter, r *http.Request) {
+	cep, ok := utils.NormalizeCep(r.PathValue("cep"))
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Erro: "CEP inválido, informe 8 dígitos"})
+		return
+	}
+
+	if data := r.URL.Query().Get("data"); data != "" {
+		s.getCepHistorico(w, cep, data)
+		return
+	}
+
+	response, err := s.storage.GetCep(cep)
+	var notFound *types.CepNotFoundError
+	if errors.As(err, &notFound) {
+		response, err = s.storage.GetCepFaixa(cep)
+	}
+
+	if errors.As(err, &notFound) {
+		writeJSON(w, http.StatusNotFound, errorResponse{Erro: "CEP não encontrado"})
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao consultar CEP %s: %v", cep, err)
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Erro: "erro interno ao consultar CEP"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) getCepHistorico(w http.ResponseWriter, cep string, data string) {
+	date, err := time.Parse(time.DateOnly, data)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Erro: "data inválida, informe no formato AAAA-MM-DD"})
+		return
+	}
+
+	response, err := s.storage.GetCepHistorico(cep, date)
+	var notFound *types.CepNotFoundError
+	if errors.As(err, &notFound) {
+		writeJSON(w, http.StatusNotFound, errorResponse{Erro: "CEP não encontrado no histórico para a data informada"})
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao consultar histórico do CEP %s: %v", cep, err)
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Erro: "erro interno ao consultar CEP"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) searchCep(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	input := types.BuscaCep{
+		UF:         strings.TrimSpace(query.Get("uf")),
+		Localidade: strings.TrimSpace(query.Get("localidade")),
+		Logradouro: strings.TrimSpace(query.Get("logradouro")),
+		Limite:     immu.BUSCA_LIMITE_PADRAO,
+	}
+
+	if len([]rune(input.Logradouro)) < 3 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Erro: "informe ao menos 3 caracteres do logradouro"})
+		return
+	}
+
+	if input.UF != "" && len(input.UF) != 2 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Erro: "UF inválida, informe a sigla com 2 letras"})
+		return
+	}
+
+	if value := query.Get("limite"); value != "" {
+		limite, err := strconv.Atoi(value)
+		if err != nil || limite < 1 || limite > immu.BUSCA_LIMITE_MAXIMO {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Erro: "limite inválido, informe um valor entre 1 e 100"})
+			return
+		}
+		input.Limite = limite
+	}
+
+	response, err := s.storage.SearchCep(input)
+	if err != nil {
+		log.Printf("Erro ao buscar logradouro %q: %v", input.Logradouro, err)
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Erro: "erro interno ao buscar CEP"})
+		return
+	}
+
+	if response == nil {
+		response = []types.CepResponse{}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) getCepNumero(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	input := types.BuscaNumero{
+		UF:         strings.TrimSpace(query.Get("uf")),
+		Localidade: strings.TrimSpace(query.Get("localidade")),
+		Logradouro: strings.TrimSpace(query.Get("logradouro")),
+	}
+
+	if input.Logradouro == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Erro: "informe o logradouro"})
+		return
+	}
+
+	if input.UF != "" && len(input.UF) != 2 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Erro: "UF inválida, informe a sigla com 2 letras"})
+		return
+	}
+
+	numero, err := strconv.Atoi(query.Get("numero"))
+	if err != nil || numero < 1 {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Erro: "número inválido, informe um inteiro positivo"})
+		return
+	}
+	input.Numero = numero
+
+	response, err := s.storage.GetCepNumero(input)
+	var notFound *types.CepNotFoundError
+	if errors.As(err, &notFound) {
+		writeJSON(w, http.StatusNotFound, errorResponse{Erro: "nenhum CEP encontrado para o endereço"})
+		return
+	}
+
+	if err != nil {
+		log.Printf("Erro ao consultar CEP de %q, %d: %v", input.Logradouro, input.Numero, err)
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Erro: "erro interno ao consultar CEP"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Erro ao serializar resposta: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+)
+
+type fakeStorage struct {
+	types.Storage
+	ceps   map[string]types.CepResponse
+	faixas map[string]types.CepResponse
+}
+
+func (s fakeStorage) GetCep(cep string) (types.CepResponse, error) {
+	if cep == "99999999" {
+		return types.CepResponse{}, errors.New("conexão perdida")
+	}
+
+	if response, ok := s.ceps[cep]; ok {
+		return response, nil
+	}
+	return types.CepResponse{}, &types.CepNotFoundError{Cep: cep}
+}
+
+func (s fakeStorage) GetCepFaixa(cep string) (types.CepResponse, error) {
+	if response, ok := s.faixas[cep]; ok {
+		return response, nil
+	}
+	return types.CepResponse{}, &types.CepNotFoundError{Cep: cep}
+}
+
+func TestGetCep(t *testing.T) {
+	handler := New(fakeStorage{
+		ceps:   map[string]types.CepResponse{"87020025": {UF: "PR", Cep: "87020025"}},
+		faixas: map[string]types.CepResponse{"69900001": {UF: "AC", Cep: "69900001"}},
+	}).Handler()
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		uf     string
+	}{
+		{name: "CEP com hífen", path: "/cep/87020-025", status: http.StatusOK, uf: "PR"},
+		{name: "CEP resolvido pela faixa", path: "/cep/69900001", status: http.StatusOK, uf: "AC"},
+		{name: "CEP inválido", path: "/cep/8702", status: http.StatusBadRequest},
+		{name: "CEP não encontrado", path: "/cep/01001000", status: http.StatusNotFound},
+		{name: "erro do banco", path: "/cep/99999999", status: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			if recorder.Code != test.status {
+				t.Fatalf("status esperado %d, obtido %d: %s", test.status, recorder.Code, recorder.Body)
+			}
+
+			if test.uf == "" {
+				return
+			}
+
+			var response types.CepResponse
+			if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+				t.Fatal(err)
+			}
+
+			if response.UF != test.uf {
+				t.Errorf("UF esperada %q, obtida %q", test.uf, response.UF)
+			}
+		})
+	}
+}
+
+func TestListenAndServeStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- New(fakeStorage{}).ListenAndServe(ctx, "127.0.0.1:0")
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("servidor não encerrou após o cancelamento do contexto")
+	}
+}
+
+func TestListenAndServeReleasesShutdownOnError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	before := runtime.NumGoroutine()
+	if err := New(fakeStorage{}).ListenAndServe(context.Background(), listener.Addr().String()); err == nil {
+		t.Fatal("esperado erro ao iniciar servidor em porta já em uso")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutine de encerramento não terminou: %d goroutines, antes %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
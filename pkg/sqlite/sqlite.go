@@ -0,0 +1,1148 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	immu "github.com/diegodario88/importador-cep-correios/pkg/constants"
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+	"github.com/diegodario88/importador-cep-correios/pkg/utils"
+	msqlite "modernc.org/sqlite"
+)
+
+const stagingPrefix = "staging_"
+
+// O SQLite não possui unaccent nem pg_trgm, então as funções usadas pela
+// busca de logradouros são implementadas em Go.
+func init() {
+	msqlite.MustRegisterDeterministicScalarFunction("normaliza", 1, func(_ *msqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		value, ok := args[0].(string)
+		if !ok {
+			return nil, nil
+		}
+		return utils.NormalizeText(value), nil
+	})
+
+	msqlite.MustRegisterDeterministicScalarFunction("similaridade", 2, func(_ *msqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		left, _ := args[0].(string)
+		right, _ := args[1].(string)
+		return utils.Similarity(left, right), nil
+	})
+}
+
+type DB struct {
+	Path string
+	conn *sql.DB
+	ctx  context.Context
+}
+
+func (db *DB) Connect() error {
+	db.ctx = context.Background()
+
+	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(10000)&_pragma=synchronous(NORMAL)", db.Path)
+	conn, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return fmt.Errorf("error opening sqlite database %s: %w", db.Path, err)
+	}
+
+	// SQLite aceita um único escritor por vez, então todas as goroutines
+	// compartilham a mesma conexão em vez de disputar o lock do arquivo.
+	conn.SetMaxOpenConns(1)
+
+	if err := conn.PingContext(db.ctx); err != nil {
+		conn.Close()
+		return fmt.Errorf("error pinging sqlite database %s: %w", db.Path, err)
+	}
+
+	db.conn = conn
+	version, err := db.Version()
+	if err != nil {
+		return fmt.Errorf("error seeking for database version: %w", err)
+	}
+
+	log.Println("------------------------------------------------------")
+	log.Println("Successfully connected to the database")
+	log.Println(version)
+	return nil
+}
+
+func (db *DB) Disconnect() {
+	if db.conn != nil {
+		db.conn.Close()
+		log.Println("Disconnected from database")
+		log.Println("------------------------------------------------------")
+	}
+}
+
+func (db *DB) Version() (string, error) {
+	var version string
+	if err := db.conn.QueryRowContext(db.ctx, "SELECT sqlite_version()").Scan(&version); err != nil {
+		return "", fmt.Errorf("erro ao obter versão do SQLite: %w", err)
+	}
+	return "SQLite " + version, nil
+}
+
+func (db *DB) CreateCorreiosSchema() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS importacao_relatorio (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		executado_em TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		tipo TEXT NOT NULL DEFAULT 'basico',
+		situacao TEXT NOT NULL DEFAULT 'concluida',
+		total_registros INTEGER NOT NULL,
+		total_ceps INTEGER NOT NULL,
+		versao_base TEXT NOT NULL,
+		duracao TEXT NOT NULL,
+		observacoes TEXT
+	);`
+
+	if _, err := db.conn.ExecContext(db.ctx, query); err != nil {
+		return fmt.Errorf("error creating importacao_relatorio table: %w", err)
+	}
+
+	if err := db.ensureColumn("importacao_relatorio", "tipo", "TEXT NOT NULL DEFAULT 'basico'"); err != nil {
+		return err
+	}
+
+	if err := db.ensureColumn("importacao_relatorio", "situacao", "TEXT NOT NULL DEFAULT 'concluida'"); err != nil {
+		return err
+	}
+
+	query = `
+	CREATE TABLE IF NOT EXISTS importacao_arquivo (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		importacao_id INTEGER NOT NULL REFERENCES importacao_relatorio (id) ON DELETE CASCADE,
+		arquivo TEXT NOT NULL,
+		linhas INTEGER NOT NULL,
+		registros INTEGER NOT NULL,
+		bytes INTEGER NOT NULL,
+		checksum TEXT,
+		duracao TEXT NOT NULL,
+		erro TEXT
+	);`
+
+	if _, err := db.conn.ExecContext(db.ctx, query); err != nil {
+		return fmt.Errorf("error creating importacao_arquivo table: %w", err)
+	}
+
+	query = `
+	CREATE TABLE IF NOT EXISTS cep_historico (
+		cep TEXT NOT NULL,
+		uf TEXT NOT NULL,
+		localidade TEXT,
+		ibge TEXT,
+		bairro TEXT,
+		complemento TEXT,
+		logradouro TEXT,
+		nome TEXT,
+		fonte TEXT NOT NULL,
+		versao_de TEXT NOT NULL,
+		versao_ate TEXT,
+		valido_de TEXT NOT NULL,
+		valido_ate TEXT,
+		PRIMARY KEY (cep, valido_de)
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS cep_historico_vigente_idx ON cep_historico (cep) WHERE valido_ate IS NULL;`
+
+	if _, err := db.conn.ExecContext(db.ctx, query); err != nil {
+		return fmt.Errorf("error creating cep_historico table: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) ensureColumn(table string, column string, definition string) error {
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM pragma_table_info(?) WHERE name = ?)"
+	if err := db.conn.QueryRowContext(db.ctx, query, table, column).Scan(&exists); err != nil {
+		return fmt.Errorf("erro ao verificar coluna %s.%s: %w", table, column, err)
+	}
+
+	if exists {
+		return nil
+	}
+
+	alter := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)
+	if _, err := db.conn.ExecContext(db.ctx, alter); err != nil {
+		return fmt.Errorf("erro ao adicionar coluna %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+func (db *DB) CreateCorreiosSql() error {
+	if err := db.CreateCorreiosSchema(); err != nil {
+		return fmt.Errorf("error creating schema: %w", err)
+	}
+
+	for _, table := range types.CorreiosTables {
+		staging := stagingPrefix + table.Name
+		columns := make([]string, len(table.Columns))
+		for i, column := range table.Columns {
+			columns[i] = column.Name + " TEXT"
+		}
+
+		query := fmt.Sprintf("DROP TABLE IF EXISTS %s; CREATE TABLE %s (%s, PRIMARY KEY (%s));",
+			staging,
+			staging,
+			strings.Join(columns, ", "),
+			strings.Join(table.PrimaryKey, ", "),
+		)
+
+		if _, err := db.conn.ExecContext(db.ctx, query); err != nil {
+			return fmt.Errorf("error creating %s table: %w", staging, err)
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) DiscardCorreiosSql() error {
+	for _, table := range loadedTables() {
+		query := fmt.Sprintf("DROP TABLE IF EXISTS %s;", stagingPrefix+table)
+		if _, err := db.conn.ExecContext(db.ctx, query); err != nil {
+			return fmt.Errorf("erro ao descartar tabela %s: %w", stagingPrefix+table, err)
+		}
+	}
+	return nil
+}
+
+func (db *DB) SwapCorreiosSchema() error {
+	tx, err := db.conn.BeginTx(db.ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar troca das tabelas: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range loadedTables() {
+		query := fmt.Sprintf("DROP TABLE IF EXISTS %s; ALTER TABLE %s RENAME TO %s;",
+			table, stagingPrefix+table, table)
+
+		if _, err := tx.ExecContext(db.ctx, query); err != nil {
+			return fmt.Errorf("erro ao trocar tabela %s: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar troca das tabelas: %w", err)
+	}
+	return nil
+}
+
+func loadedTables() []string {
+	tables := []string{"cep_enderecos"}
+	for _, table := range types.CorreiosTables {
+		tables = append(tables, table.Name)
+	}
+	return tables
+}
+
+func (db *DB) CreateCepEnderecos(ctx context.Context) error {
+	return db.buildCepEnderecos(ctx, stagingPrefix+"cep_enderecos", stagingPrefix)
+}
+
+func (db *DB) RefreshCepEnderecos(ctx context.Context) error {
+	return db.buildCepEnderecos(ctx, "cep_enderecos", "")
+}
+
+func (db *DB) buildCepEnderecos(ctx context.Context, target string, prefix string) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar criação de %s: %w", target, err)
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		"DROP TABLE IF EXISTS cep_enderecos_novo",
+		`CREATE TABLE cep_enderecos_novo (
+			cep TEXT PRIMARY KEY,
+			uf TEXT NOT NULL,
+			localidade TEXT,
+			ibge TEXT,
+			bairro TEXT,
+			complemento TEXT,
+			logradouro TEXT,
+			nome TEXT,
+			fonte TEXT NOT NULL
+		)`,
+		fmt.Sprintf(cepEnderecosQuery, "cep_enderecos_novo", prefix),
+		fmt.Sprintf("DROP TABLE IF EXISTS %s", target),
+		fmt.Sprintf("ALTER TABLE cep_enderecos_novo RENAME TO %s", target),
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("erro ao criar %s: %w", target, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar criação de %s: %w", target, err)
+	}
+	return nil
+}
+
+func (db *DB) GetTotalRecords() (int, error) {
+	counts := []string{"(SELECT count(*) FROM importacao_relatorio)"}
+	for _, table := range types.CorreiosTables {
+		counts = append(counts, fmt.Sprintf("(SELECT count(*) FROM %s)", table.Name))
+	}
+
+	var total int
+	query := "SELECT " + strings.Join(counts, " + ")
+	if err := db.conn.QueryRowContext(db.ctx, query).Scan(&total); err != nil {
+		return 0, fmt.Errorf("erro ao obter total de registros: %w", err)
+	}
+	return total, nil
+}
+
+func (db *DB) GetTotalCEPs() (int, error) {
+	query := `
+	SELECT count(*) AS total_ceps
+	FROM (
+		SELECT cep FROM log_localidade
+		UNION ALL
+		SELECT cep FROM log_logradouro
+		UNION ALL
+		SELECT cep FROM log_grande_usuario
+		UNION ALL
+		SELECT cep FROM log_unid_oper
+		UNION ALL
+		SELECT cep FROM log_cpc
+	) AS all_ceps;`
+
+	var total int
+	if err := db.conn.QueryRowContext(db.ctx, query).Scan(&total); err != nil {
+		return 0, fmt.Errorf("erro ao obter total de CEPs: %w", err)
+	}
+	return total, nil
+}
+
+func (db *DB) BulkInsertFile(ctx context.Context, fileName string, rows [][]any) error {
+	table, ok := types.TableForFile(fileName)
+	if !ok {
+		return fmt.Errorf("unknown file name: %s", fileName)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		stagingPrefix+table.Name,
+		strings.Join(table.ColumnNames(), ", "),
+		placeholders(len(table.Columns)),
+	)
+
+	if err := db.execRows(ctx, query, rows); err != nil {
+		return fmt.Errorf("error bulk inserting into %s: %w", table.Name, err)
+	}
+	return nil
+}
+
+type deltaTx struct {
+	ctx context.Context
+	tx  *sql.Tx
+}
+
+func (db *DB) BeginDelta(ctx context.Context) (types.DeltaTx, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação do delta: %w", err)
+	}
+	return &deltaTx{ctx: ctx, tx: tx}, nil
+}
+
+func (d *deltaTx) ApplyDelta(fileName string, rows [][]any) error {
+	table, ok := types.TableForFile(fileName)
+	if !ok {
+		return fmt.Errorf("unknown file name: %s", fileName)
+	}
+
+	var updates, conditions []string
+	for _, column := range table.ColumnNames() {
+		if !slices.Contains(table.PrimaryKey, column) {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", column, column))
+		}
+	}
+	for _, column := range table.PrimaryKey {
+		conditions = append(conditions, column+" = ?")
+	}
+
+	conflict := "DO NOTHING"
+	if len(updates) > 0 {
+		conflict = "DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+
+	upsert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) %s",
+		table.Name,
+		strings.Join(table.ColumnNames(), ", "),
+		placeholders(len(table.Columns)),
+		strings.Join(table.PrimaryKey, ", "),
+		conflict,
+	)
+	remove := fmt.Sprintf("DELETE FROM %s WHERE %s", table.Name, strings.Join(conditions, " AND "))
+
+	var err error
+	for i, row := range rows {
+		if len(row) != len(table.Columns)+1 {
+			return fmt.Errorf("%s linha %d: esperadas %d colunas, encontradas %d",
+				fileName, i+1, len(table.Columns)+1, len(row))
+		}
+
+		values := row[:len(table.Columns)]
+		operation, _ := row[len(table.Columns)].(string)
+
+		switch operation {
+		case immu.DELTA_INSERT, immu.DELTA_UPDATE:
+			_, err = d.tx.ExecContext(d.ctx, upsert, values...)
+		case immu.DELTA_DELETE:
+			keys := make([]any, 0, len(table.PrimaryKey))
+			for _, column := range table.PrimaryKey {
+				keys = append(keys, values[slices.Index(table.ColumnNames(), column)])
+			}
+			_, err = d.tx.ExecContext(d.ctx, remove, keys...)
+		default:
+			return fmt.Errorf("%s linha %d: operação desconhecida %q", fileName, i+1, operation)
+		}
+
+		if err != nil {
+			return fmt.Errorf("erro ao aplicar delta %s em %s: %w", fileName, table.Name, err)
+		}
+	}
+	return nil
+}
+
+func (d *deltaTx) Commit() error {
+	if err := d.tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar delta: %w", err)
+	}
+	return nil
+}
+
+func (d *deltaTx) Rollback() error {
+	if err := d.tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return fmt.Errorf("erro ao descartar delta: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) GetCep(cep string) (types.CepResponse, error) {
+	response, err := db.queryCep(consultaCepQuery, cep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.CepResponse{}, &types.CepNotFoundError{Cep: cep}
+	}
+	return response, err
+}
+
+func (db *DB) GetCepFaixa(cep string) (types.CepResponse, error) {
+	response, err := db.queryCep(consultaFaixaCepQuery, cep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.CepResponse{}, &types.CepNotFoundError{Cep: cep}
+	}
+	return response, err
+}
+
+func (db *DB) SearchCep(input types.BuscaCep) ([]types.CepResponse, error) {
+	rows, err := db.conn.QueryContext(db.ctx, buscaCepQuery,
+		strings.ToUpper(input.UF),
+		utils.NormalizeText(input.Localidade),
+		utils.NormalizeText(input.Logradouro),
+		input.Limite,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar CEP: %w", err)
+	}
+	defer rows.Close()
+
+	var response []types.CepResponse
+	for rows.Next() {
+		var item types.CepResponse
+		err := rows.Scan(
+			&item.UF,
+			&item.Localidade,
+			&item.Cep,
+			&item.IBGE,
+			&item.Bairro,
+			&item.Complemento,
+			&item.Logradouro,
+			&item.Nome,
+			&item.Fonte,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar CEP: %w", err)
+		}
+		response = append(response, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao buscar CEP: %w", err)
+	}
+	return response, nil
+}
+
+func (db *DB) GetCepNumero(input types.BuscaNumero) (types.CepResponse, error) {
+	response, err := db.queryCep(consultaCepNumeroQuery,
+		strings.ToUpper(input.UF),
+		utils.NormalizeText(input.Localidade),
+		utils.NormalizeText(input.Logradouro),
+		input.Numero,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.CepResponse{}, &types.CepNotFoundError{Cep: fmt.Sprintf("%s, %d", input.Logradouro, input.Numero)}
+	}
+	return response, err
+}
+
+func (db *DB) GetCepHistorico(cep string, data time.Time) (types.CepHistorico, error) {
+	query := `
+	SELECT uf, localidade, cep, ibge, bairro, complemento, logradouro, nome, fonte, versao_de, valido_de, valido_ate
+	FROM cep_historico
+	WHERE cep = ?1
+		AND valido_de < date(?2, '+1 day')
+		AND (valido_ate IS NULL OR valido_ate >= date(?2, '+1 day'))
+	ORDER BY valido_de DESC
+	LIMIT 1`
+
+	var response types.CepHistorico
+	var validoDe string
+	var validoAte sql.NullString
+	err := db.conn.QueryRowContext(db.ctx, query, cep, data.Format(time.DateOnly)).Scan(
+		&response.UF,
+		&response.Localidade,
+		&response.Cep,
+		&response.IBGE,
+		&response.Bairro,
+		&response.Complemento,
+		&response.Logradouro,
+		&response.Nome,
+		&response.Fonte,
+		&response.VersaoEDNE,
+		&validoDe,
+		&validoAte,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.CepHistorico{}, &types.CepNotFoundError{Cep: cep}
+	}
+
+	if err != nil {
+		return types.CepHistorico{}, fmt.Errorf("erro ao consultar histórico do CEP: %w", err)
+	}
+
+	response.ValidoDe, _ = time.Parse(time.DateTime, validoDe)
+	if validoAte.Valid {
+		validoAte, _ := time.Parse(time.DateTime, validoAte.String)
+		response.ValidoAte = &validoAte
+	}
+	return response, nil
+}
+
+// Histórico tipo 2: encerra a versão vigente dos CEPs removidos ou alterados
+// e abre uma nova para os CEPs sem versão vigente, na mesma transação.
+func (db *DB) UpdateCepHistorico(versao string) (int, int, error) {
+	tx, err := db.conn.BeginTx(db.ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("erro ao iniciar atualização de cep_historico: %w", err)
+	}
+	defer tx.Rollback()
+
+	encerra := `
+	UPDATE cep_historico
+	SET valido_ate = ?1, versao_ate = ?2
+	WHERE valido_ate IS NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM cep_enderecos e
+			WHERE e.cep = cep_historico.cep
+				AND e.uf IS cep_historico.uf
+				AND e.localidade IS cep_historico.localidade
+				AND e.ibge IS cep_historico.ibge
+				AND e.bairro IS cep_historico.bairro
+				AND e.complemento IS cep_historico.complemento
+				AND e.logradouro IS cep_historico.logradouro
+				AND e.nome IS cep_historico.nome
+				AND e.fonte IS cep_historico.fonte)`
+
+	abre := `
+	INSERT INTO cep_historico (cep, uf, localidade, ibge, bairro, complemento, logradouro, nome, fonte, versao_de, valido_de)
+	SELECT e.cep, e.uf, e.localidade, e.ibge, e.bairro, e.complemento, e.logradouro, e.nome, e.fonte, ?2, ?1
+	FROM cep_enderecos e
+	WHERE NOT EXISTS (
+		SELECT 1 FROM cep_historico h
+		WHERE h.cep = e.cep AND h.valido_ate IS NULL)`
+
+	// Gravado em UTC, como o timestamptz do PostgreSQL, para que a consulta
+	// por data compare com o fim do dia em UTC nos dois backends.
+	agora := time.Now().UTC().Format(time.DateTime + ".000")
+	encerrados, err := tx.ExecContext(db.ctx, encerra, agora, versao)
+	if err != nil {
+		return 0, 0, fmt.Errorf("erro ao encerrar versões de cep_historico: %w", err)
+	}
+
+	abertos, err := tx.ExecContext(db.ctx, abre, agora, versao)
+	if err != nil {
+		return 0, 0, fmt.Errorf("erro ao abrir versões de cep_historico: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("erro ao confirmar atualização de cep_historico: %w", err)
+	}
+
+	totalEncerrados, _ := encerrados.RowsAffected()
+	totalAbertos, _ := abertos.RowsAffected()
+	return int(totalAbertos), int(totalEncerrados), nil
+}
+
+func (db *DB) queryCep(query string, args ...any) (types.CepResponse, error) {
+	var response types.CepResponse
+	err := db.conn.QueryRowContext(db.ctx, query, args...).Scan(
+		&response.UF,
+		&response.Localidade,
+		&response.Cep,
+		&response.IBGE,
+		&response.Bairro,
+		&response.Complemento,
+		&response.Logradouro,
+		&response.Nome,
+		&response.Fonte,
+	)
+	if err != nil {
+		return types.CepResponse{}, fmt.Errorf("erro ao consultar CEP: %w", err)
+	}
+	return response, nil
+}
+
+func (db *DB) StreamTable(ctx context.Context, table types.Table, handle func(row []any) error) error {
+	// As colunas são TEXT; as numéricas da chave são ordenadas pelo valor,
+	// como no PostgreSQL, para o diff intercalar as linhas.
+	order := make([]string, len(table.PrimaryKey))
+	for i, name := range table.PrimaryKey {
+		order[i] = name
+		if column, ok := table.Column(name); ok && column.Numeric {
+			order[i] = "CAST(" + name + " AS INTEGER)"
+		}
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s ORDER BY %s",
+		strings.Join(table.ColumnNames(), ", "),
+		table.Name,
+		strings.Join(order, ", "),
+	)
+
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("erro ao ler tabela %s: %w", table.Name, err)
+	}
+	defer rows.Close()
+
+	values := make([]sql.NullString, len(table.Columns))
+	targets := make([]any, len(table.Columns))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(targets...); err != nil {
+			return fmt.Errorf("erro ao ler tabela %s: %w", table.Name, err)
+		}
+
+		row := make([]any, len(values))
+		for i, value := range values {
+			if value.Valid {
+				row[i] = value.String
+			}
+		}
+
+		if err := handle(row); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("erro ao ler tabela %s: %w", table.Name, err)
+	}
+	return nil
+}
+
+func (db *DB) InsertImportacaoRelatorio(input types.ImportacaoRelatorio) (int, error) {
+	query := `
+	INSERT INTO importacao_relatorio (
+		tipo,
+		situacao,
+		total_registros,
+		total_ceps,
+		versao_base,
+		duracao,
+		observacoes
+	) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := db.conn.ExecContext(db.ctx, query,
+		input.Tipo,
+		input.Situacao,
+		input.TotalRegistros,
+		input.TotalCeps,
+		input.VersaoEDNE,
+		input.Duracao.String(),
+		input.Observacoes,
+	)
+
+	if err != nil {
+		return 0, fmt.Errorf("erro ao inserir relatório de importação: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao inserir relatório de importação: %w", err)
+	}
+
+	return int(id), nil
+}
+
+func (db *DB) InsertImportacaoArquivos(importacaoID int, arquivos []types.ImportacaoArquivo) error {
+	query := `
+	INSERT INTO importacao_arquivo (
+		importacao_id,
+		arquivo,
+		linhas,
+		registros,
+		bytes,
+		checksum,
+		duracao,
+		erro
+	) VALUES (?, ?, ?, ?, ?, nullif(?, ''), ?, nullif(?, ''))
+	`
+
+	rows := make([][]any, len(arquivos))
+	for i, arquivo := range arquivos {
+		rows[i] = []any{
+			importacaoID,
+			arquivo.Arquivo,
+			arquivo.Linhas,
+			arquivo.Registros,
+			arquivo.Bytes,
+			arquivo.Checksum,
+			arquivo.Duracao.String(),
+			arquivo.Erro,
+		}
+	}
+
+	if err := db.execRows(db.ctx, query, rows); err != nil {
+		return fmt.Errorf("erro ao inserir relatório dos arquivos: %w", err)
+	}
+	return nil
+}
+
+func (db *DB) ListImportacaoArquivos(importacaoID int) ([]types.ImportacaoArquivo, error) {
+	query := `
+	SELECT arquivo, linhas, registros, bytes, coalesce(checksum, ''), duracao, coalesce(erro, '')
+	FROM importacao_arquivo
+	WHERE importacao_id = ?
+	ORDER BY arquivo`
+
+	rows, err := db.conn.QueryContext(db.ctx, query, importacaoID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar relatório dos arquivos: %w", err)
+	}
+	defer rows.Close()
+
+	var arquivos []types.ImportacaoArquivo
+	for rows.Next() {
+		var arquivo types.ImportacaoArquivo
+		var duracao string
+		err := rows.Scan(
+			&arquivo.Arquivo,
+			&arquivo.Linhas,
+			&arquivo.Registros,
+			&arquivo.Bytes,
+			&arquivo.Checksum,
+			&duracao,
+			&arquivo.Erro,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar relatório dos arquivos: %w", err)
+		}
+
+		arquivo.Duracao, _ = time.ParseDuration(duracao)
+		arquivos = append(arquivos, arquivo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao listar relatório dos arquivos: %w", err)
+	}
+	return arquivos, nil
+}
+
+func (db *DB) ListImportacaoRelatorio(limite int) ([]types.ImportacaoRelatorio, error) {
+	query := `
+	SELECT id, executado_em, tipo, situacao, total_registros, total_ceps, versao_base, duracao, coalesce(observacoes, '')
+	FROM importacao_relatorio
+	ORDER BY id DESC
+	LIMIT ?`
+
+	rows, err := db.conn.QueryContext(db.ctx, query, limite)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar relatórios de importação: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []types.ImportacaoRelatorio
+	for rows.Next() {
+		var report types.ImportacaoRelatorio
+		var executadoEm, duracao string
+		err := rows.Scan(
+			&report.ID,
+			&executadoEm,
+			&report.Tipo,
+			&report.Situacao,
+			&report.TotalRegistros,
+			&report.TotalCeps,
+			&report.VersaoEDNE,
+			&duracao,
+			&report.Observacoes,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar relatórios de importação: %w", err)
+		}
+
+		report.ExecutadoEm, _ = time.Parse(time.DateTime, executadoEm)
+		report.Duracao, _ = time.ParseDuration(duracao)
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao listar relatórios de importação: %w", err)
+	}
+	return reports, nil
+}
+
+// Copia para o staging as tabelas que não fazem parte da carga atual, para
+// que a troca das tabelas não as deixe vazias.
+func (db *DB) CopyCorreiosTables(tables []types.Table) error {
+	for _, table := range tables {
+		var exists bool
+		err := db.conn.QueryRowContext(db.ctx,
+			"SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?)", table.Name,
+		).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("erro ao verificar tabela %s: %w", table.Name, err)
+		}
+
+		if !exists {
+			continue
+		}
+
+		query := fmt.Sprintf("INSERT INTO %s%s SELECT * FROM %s", stagingPrefix, table.Name, table.Name)
+		if _, err := db.conn.ExecContext(db.ctx, query); err != nil {
+			return fmt.Errorf("erro ao copiar tabela %s: %w", table.Name, err)
+		}
+	}
+	return nil
+}
+
+func (db *DB) ExistsImportacaoVersao(tipo string, versao string) (bool, error) {
+	query := `
+	SELECT EXISTS (
+		SELECT 1 FROM importacao_relatorio
+		WHERE tipo = ? AND versao_base = ? AND situacao IN ('concluida', 'pendente')
+	)`
+
+	var exists bool
+	if err := db.conn.QueryRowContext(db.ctx, query, tipo, versao).Scan(&exists); err != nil {
+		return false, fmt.Errorf("erro ao verificar versão importada: %w", err)
+	}
+	return exists, nil
+}
+
+func (db *DB) CheckIntegrity() ([]types.IntegrityIssue, error) {
+	var issues []types.IntegrityIssue
+	for _, table := range types.CorreiosTables {
+		for _, reference := range table.References {
+			query := fmt.Sprintf(`
+			SELECT count(*) FROM %[1]s%[2]s t
+			WHERE t.%[3]s IS NOT NULL AND NOT EXISTS (
+				SELECT 1 FROM %[1]s%[4]s r WHERE r.%[5]s = t.%[3]s
+			)`, stagingPrefix, table.Name, reference.Column, reference.Table, reference.TargetColumn)
+
+			var orphans int
+			if err := db.conn.QueryRowContext(db.ctx, query).Scan(&orphans); err != nil {
+				return nil, fmt.Errorf("erro ao verificar %s.%s: %w", table.Name, reference.Column, err)
+			}
+
+			issues = append(issues, types.IntegrityIssue{Table: table.Name, Reference: reference, Orphans: orphans})
+		}
+	}
+	return issues, nil
+}
+
+// O SQLite não permite adicionar constraints com ALTER TABLE.
+func (db *DB) CreateForeignKeys() error {
+	return errors.New("chaves estrangeiras não são suportadas com -storage sqlite")
+}
+
+// Sem trigramas indexados, a busca percorre os logradouros da UF; apenas os
+// índices de CEP e de referência têm equivalente aqui. Os nomes de índice são
+// globais no SQLite e o índice acompanha a tabela no RENAME, então a tabela
+// atual mantém os seus até a troca e o staging usa o nome que estiver livre,
+// alternando a cada importação.
+func (db *DB) CreateIndexes(ctx context.Context, concurrently bool) ([]types.IndexBuild, error) {
+	var builds []types.IndexBuild
+	for _, table := range types.CorreiosTables {
+		for _, column := range table.IndexedColumns() {
+			start := time.Now()
+			name, err := db.freeIndexName(fmt.Sprintf("%s_%s_idx", table.Name, column))
+			if err != nil {
+				return nil, err
+			}
+
+			query := fmt.Sprintf("CREATE INDEX %s ON %s%s (%s);", name, stagingPrefix, table.Name, column)
+			if _, err := db.conn.ExecContext(ctx, query); err != nil {
+				return nil, fmt.Errorf("erro ao criar índice %s: %w", name, err)
+			}
+
+			builds = append(builds, types.IndexBuild{Table: table.Name, Index: name, Duracao: time.Since(start).Round(time.Millisecond)})
+		}
+	}
+	return builds, nil
+}
+
+func (db *DB) freeIndexName(name string) (string, error) {
+	var used int
+	query := "SELECT count(*) FROM sqlite_master WHERE type = 'index' AND name = ?"
+	if err := db.conn.QueryRowContext(db.ctx, query, name).Scan(&used); err != nil {
+		return "", fmt.Errorf("erro ao verificar índice %s: %w", name, err)
+	}
+
+	if used > 0 {
+		return name + "_b", nil
+	}
+	return name, nil
+}
+
+func (db *DB) execRows(ctx context.Context, query string, rows [][]any) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+const consultaCepQuery = `
+	SELECT uf, localidade, cep, ibge, bairro, complemento, logradouro, nome, fonte
+	FROM cep_enderecos
+	WHERE cep = ?1;`
+
+// Uma linha por CEP: quando o mesmo CEP aparece em mais de uma tabela de
+// origem, prevalece a de menor prioridade, como o DISTINCT ON do PostgreSQL.
+// O INSERT simples faz qualquer violação de restrição falhar a montagem.
+const cepEnderecosQuery = `
+	INSERT INTO %[1]s (cep, uf, localidade, ibge, bairro, complemento, logradouro, nome, fonte)
+	SELECT cep, uf, localidade, ibge, bairro, complemento, logradouro, nome, fonte
+	FROM (
+		SELECT *, row_number() OVER (PARTITION BY cep ORDER BY prioridade) AS posicao
+		FROM (
+			SELECT
+				llog.cep,
+				llog.ufe_sg AS uf,
+				CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.loc_no ELSE ll2.loc_no END AS localidade,
+				CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.mun_nu ELSE ll2.mun_nu END AS ibge,
+				lb.bai_no AS bairro,
+				llog.log_complemento AS complemento,
+				llog.tlo_tx || ' ' || llog.log_no AS logradouro,
+				NULL AS nome,
+				'log_logradouro' AS fonte,
+				1 AS prioridade
+			FROM %[2]slog_logradouro llog
+			JOIN %[2]slog_localidade ll ON ll.loc_nu = llog.loc_nu
+			LEFT JOIN %[2]slog_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub AND ll.loc_in_tipo_loc <> 'M'
+			LEFT JOIN %[2]slog_bairro lb ON lb.bai_nu = llog.bai_nu_ini
+			UNION ALL
+			SELECT
+				lgu.cep,
+				lgu.ufe_sg AS uf,
+				CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.loc_no_abrev ELSE ll2.loc_no END AS localidade,
+				CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.mun_nu ELSE ll2.mun_nu END AS ibge,
+				lb.bai_no AS bairro,
+				NULL AS complemento,
+				lgu.gru_endereco AS logradouro,
+				lgu.gru_no AS nome,
+				'log_grande_usuario' AS fonte,
+				2 AS prioridade
+			FROM %[2]slog_grande_usuario lgu
+			JOIN %[2]slog_localidade ll ON ll.loc_nu = lgu.loc_nu
+			LEFT JOIN %[2]slog_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub AND ll.loc_in_tipo_loc <> 'M'
+			LEFT JOIN %[2]slog_bairro lb ON lb.bai_nu = lgu.bai_nu
+			UNION ALL
+			SELECT
+				luo.cep,
+				luo.ufe_sg AS uf,
+				CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.loc_no ELSE ll2.loc_no END AS localidade,
+				CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.mun_nu ELSE ll2.mun_nu END AS ibge,
+				lb.bai_no AS bairro,
+				NULL AS complemento,
+				luo.uop_endereco AS logradouro,
+				luo.uop_no AS nome,
+				'log_unid_oper' AS fonte,
+				3 AS prioridade
+			FROM %[2]slog_unid_oper luo
+			JOIN %[2]slog_localidade ll ON ll.loc_nu = luo.loc_nu
+			LEFT JOIN %[2]slog_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub AND ll.loc_in_tipo_loc <> 'M'
+			LEFT JOIN %[2]slog_bairro lb ON lb.bai_nu = luo.bai_nu
+			UNION ALL
+			SELECT
+				lcpc.cep,
+				lcpc.ufe_sg AS uf,
+				CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.loc_no ELSE ll2.loc_no END AS localidade,
+				CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.mun_nu ELSE ll2.mun_nu END AS ibge,
+				NULL AS bairro,
+				NULL AS complemento,
+				lcpc.cpc_endereco AS logradouro,
+				lcpc.cpc_no AS nome,
+				'log_cpc' AS fonte,
+				4 AS prioridade
+			FROM %[2]slog_cpc lcpc
+			JOIN %[2]slog_localidade ll ON ll.loc_nu = lcpc.loc_nu
+			LEFT JOIN %[2]slog_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub AND ll.loc_in_tipo_loc <> 'M'
+			UNION ALL
+			SELECT
+				ll.cep,
+				ll.ufe_sg AS uf,
+				CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.loc_no ELSE ll2.loc_no END AS localidade,
+				CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.mun_nu ELSE ll2.mun_nu END AS ibge,
+				NULL AS bairro,
+				NULL AS complemento,
+				NULL AS logradouro,
+				NULL AS nome,
+				'log_localidade' AS fonte,
+				5 AS prioridade
+			FROM %[2]slog_localidade ll
+			LEFT JOIN %[2]slog_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub AND ll.loc_in_tipo_loc <> 'M'
+			WHERE ll.cep IS NOT NULL
+		)
+	)
+	WHERE posicao = 1;`
+
+const consultaFaixaCepQuery = `
+	SELECT
+		fu.ufe_sg AS uf,
+		CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.loc_no ELSE ll2.loc_no END AS localidade,
+		?1 AS cep,
+		CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.mun_nu ELSE ll2.mun_nu END AS ibge,
+		(
+			SELECT lb.bai_no
+			FROM log_faixa_bairro fb
+			JOIN log_bairro lb ON lb.bai_nu = fb.bai_nu
+			WHERE lb.loc_nu = ll.loc_nu AND ?1 BETWEEN fb.fcb_cep_ini AND fb.fcb_cep_fim
+			ORDER BY CAST(fb.fcb_cep_fim AS INTEGER) - CAST(fb.fcb_cep_ini AS INTEGER)
+			LIMIT 1
+		) AS bairro,
+		NULL AS complemento,
+		NULL AS logradouro,
+		NULL AS nome,
+		CASE WHEN ll.loc_nu IS NULL THEN 'log_faixa_uf' ELSE 'log_faixa_localidade' END AS fonte
+	FROM log_faixa_uf fu
+	LEFT JOIN log_localidade ll ON ll.loc_nu = (
+		SELECT fl.loc_nu
+		FROM log_faixa_localidade fl
+		WHERE ?1 BETWEEN fl.loc_cep_ini AND fl.loc_cep_fim
+		ORDER BY CAST(fl.loc_cep_fim AS INTEGER) - CAST(fl.loc_cep_ini AS INTEGER)
+		LIMIT 1
+	)
+	LEFT JOIN log_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub AND ll.loc_in_tipo_loc <> 'M'
+	WHERE ?1 BETWEEN fu.ufe_cep_ini AND fu.ufe_cep_fim
+	LIMIT 1;`
+
+const buscaCepQuery = `
+	WITH candidatos AS (
+		SELECT llog.log_nu, normaliza(llog.log_no) AS texto
+		FROM log_logradouro llog
+		WHERE ?1 = '' OR llog.ufe_sg = ?1
+		UNION ALL
+		SELECT llog.log_nu, normaliza(llog.tlo_tx || ' ' || llog.log_no) AS texto
+		FROM log_logradouro llog
+		WHERE ?1 = '' OR llog.ufe_sg = ?1
+		UNION ALL
+		SELECT lvl.log_nu, normaliza(lvl.vlo_tx) AS texto
+		FROM log_var_log lvl
+	),
+	ranqueados AS (
+		SELECT
+			c.log_nu,
+			max(c.texto LIKE ?3 || '%') AS prefixo,
+			max(similaridade(c.texto, ?3)) AS similaridade
+		FROM candidatos c
+		WHERE c.texto LIKE ?3 || '%' OR similaridade(c.texto, ?3) >= 0.3
+		GROUP BY c.log_nu
+	)
+	SELECT
+		llog.ufe_sg AS uf,
+		CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.loc_no ELSE ll2.loc_no END AS localidade,
+		llog.cep,
+		CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.mun_nu ELSE ll2.mun_nu END AS ibge,
+		lb.bai_no AS bairro,
+		llog.log_complemento AS complemento,
+		llog.tlo_tx || ' ' || llog.log_no AS logradouro,
+		NULL AS nome,
+		'log_logradouro' AS fonte
+	FROM ranqueados r
+	JOIN log_logradouro llog ON llog.log_nu = r.log_nu
+	JOIN log_localidade ll ON ll.loc_nu = llog.loc_nu
+	LEFT JOIN log_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub AND ll.loc_in_tipo_loc <> 'M'
+	LEFT JOIN log_bairro lb ON lb.bai_nu = llog.bai_nu_ini
+	WHERE (?1 = '' OR llog.ufe_sg = ?1)
+		AND (?2 = '' OR normaliza(ll.loc_no) = ?2 OR normaliza(ll2.loc_no) = ?2)
+	ORDER BY r.prefixo DESC, r.similaridade DESC, 7
+	LIMIT ?4;`
+
+const consultaCepNumeroQuery = `
+	SELECT
+		llog.ufe_sg AS uf,
+		CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.loc_no ELSE ll2.loc_no END AS localidade,
+		llog.cep,
+		CASE WHEN ll.loc_in_tipo_loc = 'M' THEN ll.mun_nu ELSE ll2.mun_nu END AS ibge,
+		lb.bai_no AS bairro,
+		llog.log_complemento AS complemento,
+		llog.tlo_tx || ' ' || llog.log_no AS logradouro,
+		NULL AS nome,
+		'log_logradouro' AS fonte
+	FROM log_logradouro llog
+	JOIN log_localidade ll ON ll.loc_nu = llog.loc_nu
+	LEFT JOIN log_localidade ll2 ON ll2.loc_nu = ll.loc_nu_sub AND ll.loc_in_tipo_loc <> 'M'
+	LEFT JOIN log_bairro lb ON lb.bai_nu = llog.bai_nu_ini
+	LEFT JOIN log_num_sec lns ON lns.log_nu = llog.log_nu
+	WHERE (?1 = '' OR llog.ufe_sg = ?1)
+		AND (?2 = '' OR normaliza(ll.loc_no) = ?2 OR normaliza(ll2.loc_no) = ?2)
+		AND (normaliza(llog.log_no) = ?3 OR normaliza(llog.tlo_tx || ' ' || llog.log_no) = ?3)
+		AND (
+			lns.log_nu IS NULL
+			OR (
+				?4 BETWEEN CAST(lns.sec_nu_ini AS INTEGER) AND CAST(lns.sec_nu_fim AS INTEGER)
+				AND (
+					lns.sec_in_lado NOT IN ('P', 'I')
+					OR (lns.sec_in_lado = 'P' AND ?4 % 2 = 0)
+					OR (lns.sec_in_lado = 'I' AND ?4 % 2 = 1)
+				)
+			)
+		)
+	ORDER BY lns.log_nu IS NULL, CAST(lns.sec_nu_fim AS INTEGER) - CAST(lns.sec_nu_ini AS INTEGER)
+	LIMIT 1;`
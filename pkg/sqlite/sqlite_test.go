@@ -0,0 +1,448 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	immu "github.com/diegodario88/importador-cep-correios/pkg/constants"
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+)
+
+// Cria uma base com os arquivos informados já promovidos para as tabelas
+// finais, como ao final de uma importação.
+func newTestDB(t *testing.T, files map[string][][]any) *DB {
+	t.Helper()
+
+	db := &DB{Path: filepath.Join(t.TempDir(), "correios.db")}
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Disconnect)
+
+	if err := db.CreateCorreiosSchema(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.CreateCorreiosSql(); err != nil {
+		t.Fatal(err)
+	}
+
+	for fileName, rows := range files {
+		if err := db.BulkInsertFile(context.Background(), fileName, rows); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := db.CreateCepEnderecos(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.SwapCorreiosSchema(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func queryStrings(t *testing.T, db *DB, query string, args ...any) []string {
+	t.Helper()
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return values
+}
+
+var maringa = []any{"1", "PR", "Maringá", "87000000", "0", "M", nil, "Maringá", "4115200"}
+
+func TestApplyDelta(t *testing.T) {
+	base := map[string][][]any{
+		"LOG_LOCALIDADE.TXT": {maringa},
+		"LOG_BAIRRO.TXT": {
+			{"10", "PR", "1", "Centro", "Centro"},
+			{"11", "PR", "1", "Zona 7", "Zona 7"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		rows    [][]any
+		commit  bool
+		wantErr bool
+		bairros []string
+	}{
+		{
+			name: "insere, altera e remove",
+			file: "DELTA_LOG_BAIRRO.TXT",
+			rows: [][]any{
+				{"12", "PR", "1", "Zona 5", "Zona 5", "INS"},
+				{"10", "PR", "1", "Centro Cívico", "Centro", "UPD"},
+				{"11", "PR", "1", "Zona 7", "Zona 7", "DEL"},
+			},
+			commit:  true,
+			bairros: []string{"Centro Cívico", "Zona 5"},
+		},
+		{
+			name: "rollback descarta as operações",
+			file: "DELTA_LOG_BAIRRO.TXT",
+			rows: [][]any{
+				{"12", "PR", "1", "Zona 5", "Zona 5", "INS"},
+				{"11", "PR", "1", "Zona 7", "Zona 7", "DEL"},
+			},
+			bairros: []string{"Centro", "Zona 7"},
+		},
+		{
+			name:    "operação desconhecida",
+			file:    "DELTA_LOG_BAIRRO.TXT",
+			rows:    [][]any{{"12", "PR", "1", "Zona 5", "Zona 5", "XXX"}},
+			wantErr: true,
+			bairros: []string{"Centro", "Zona 7"},
+		},
+		{
+			name:    "linha sem a operação",
+			file:    "DELTA_LOG_BAIRRO.TXT",
+			rows:    [][]any{{"12", "PR", "1", "Zona 5", "Zona 5"}},
+			wantErr: true,
+			bairros: []string{"Centro", "Zona 7"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := newTestDB(t, base)
+
+			tx, err := db.BeginDelta(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			err = tx.ApplyDelta(test.file, test.rows)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+
+			if test.commit {
+				if err := tx.Commit(); err != nil {
+					t.Fatal(err)
+				}
+			} else if err := tx.Rollback(); err != nil {
+				t.Fatal(err)
+			}
+
+			bairros := queryStrings(t, db, "SELECT bai_no FROM log_bairro ORDER BY bai_no")
+			if len(bairros) != len(test.bairros) {
+				t.Fatalf("bairros esperados %v, obtidos %v", test.bairros, bairros)
+			}
+
+			for i := range bairros {
+				if bairros[i] != test.bairros[i] {
+					t.Fatalf("bairros esperados %v, obtidos %v", test.bairros, bairros)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateIndexesKeepsCurrentIndexes(t *testing.T) {
+	db := newTestDB(t, nil)
+	query := "SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND name NOT LIKE 'sqlite_%' ORDER BY name"
+
+	for i := 0; i < 3; i++ {
+		current := queryStrings(t, db, query, "log_bairro")
+
+		if err := db.CreateCorreiosSql(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := db.CreateIndexes(context.Background(), false); err != nil {
+			t.Fatal(err)
+		}
+
+		if kept := queryStrings(t, db, query, "log_bairro"); !slices.Equal(kept, current) {
+			t.Fatalf("importação %d: índices da tabela atual alterados antes da troca: %v, antes %v", i+1, kept, current)
+		}
+
+		if staged := queryStrings(t, db, query, stagingPrefix+"log_bairro"); len(staged) == 0 {
+			t.Fatalf("importação %d: staging sem índices", i+1)
+		}
+
+		if err := db.CreateCepEnderecos(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := db.SwapCorreiosSchema(); err != nil {
+			t.Fatal(err)
+		}
+
+		if swapped := queryStrings(t, db, query, "log_bairro"); len(swapped) == 0 {
+			t.Fatalf("importação %d: tabela sem índices após a troca", i+1)
+		}
+	}
+}
+
+func TestGetCepFromCepEnderecos(t *testing.T) {
+	db := newTestDB(t, map[string][][]any{
+		"LOG_LOCALIDADE.TXT": {
+			maringa,
+			{"2", "PR", "Sarandi", nil, "0", "M", nil, nil, "4126256"},
+		},
+		"LOG_BAIRRO.TXT": {{"10", "PR", "1", "Centro", "Centro"}},
+		"LOG_LOGRADOURO_PR.TXT": {
+			{"1", "PR", "1", "10", nil, "Brasil", "de 1001 a 2000", "87013001", "Avenida", "S", "Av Brasil"},
+		},
+		"LOG_GRANDE_USUARIO.TXT": {
+			{"1", "PR", "1", "10", nil, "Prefeitura", "Av XV de Novembro, 701", "87000000", nil},
+			{"2", "PR", "2", "10", nil, "Prefeitura de Sarandi", "Rua José Emiliano, 1", "87111971", nil},
+		},
+	})
+
+	value := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+
+	tests := []struct {
+		name string
+		cep  string
+		want []string
+	}{
+		{
+			name: "logradouro",
+			cep:  "87013001",
+			want: []string{"PR", "Maringá", "4115200", "Centro", "de 1001 a 2000", "Avenida Brasil", "", "log_logradouro"},
+		},
+		{
+			name: "grande usuário prevalece sobre a localidade",
+			cep:  "87000000",
+			want: []string{"PR", "Maringá", "4115200", "Centro", "", "Av XV de Novembro, 701", "Prefeitura", "log_grande_usuario"},
+		},
+		{
+			name: "município sem nome abreviado",
+			cep:  "87111971",
+			want: []string{"PR", "", "4126256", "Centro", "", "Rua José Emiliano, 1", "Prefeitura de Sarandi", "log_grande_usuario"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := db.GetCep(test.cep)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []string{response.UF, value(response.Localidade), value(response.IBGE), value(response.Bairro),
+				value(response.Complemento), value(response.Logradouro), value(response.Nome), response.Fonte}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("esperado %q, obtido %q", test.want, got)
+			}
+		})
+	}
+
+	var notFound *types.CepNotFoundError
+	if _, err := db.GetCep("01001000"); !errors.As(err, &notFound) {
+		t.Errorf("esperado CEP não encontrado, obtido %v", err)
+	}
+}
+
+func TestStreamTableOrdersNumericKeys(t *testing.T) {
+	db := newTestDB(t, map[string][][]any{
+		"LOG_LOCALIDADE.TXT": {maringa},
+		"LOG_BAIRRO.TXT": {
+			{"10", "PR", "1", "Zona 10", nil},
+			{"9", "PR", "1", "Zona 9", nil},
+			{"100", "PR", "1", "Zona 100", nil},
+		},
+	})
+
+	table, _ := types.TableByName("log_bairro")
+	var keys []any
+	err := db.StreamTable(context.Background(), table, func(row []any) error {
+		keys = append(keys, row[0])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// As colunas são TEXT, mas bai_nu precisa sair na ordem numérica do diff.
+	if want := []any{"9", "10", "100"}; !slices.Equal(keys, want) {
+		t.Errorf("chaves esperadas %v, obtidas %v", want, keys)
+	}
+}
+
+func TestGetCepFaixa(t *testing.T) {
+	db := newTestDB(t, map[string][][]any{
+		"LOG_FAIXA_UF.TXT": {
+			{"AC", "69900000", "69999999"},
+			{"PR", "80000000", "87999999"},
+		},
+		"LOG_LOCALIDADE.TXT":       {maringa},
+		"LOG_FAIXA_LOCALIDADE.TXT": {{"1", "87000000", "87099999", "T"}},
+		"LOG_BAIRRO.TXT":           {{"10", "PR", "1", "Centro", "Centro"}},
+		"LOG_FAIXA_BAIRRO.TXT":     {{"10", "87013000", "87013999"}},
+	})
+
+	tests := []struct {
+		name       string
+		cep        string
+		uf         string
+		localidade string
+		bairro     string
+		fonte      string
+		notFound   bool
+	}{
+		{name: "faixa de bairro", cep: "87013001", uf: "PR", localidade: "Maringá", bairro: "Centro", fonte: "log_faixa_localidade"},
+		{name: "faixa de localidade", cep: "87050000", uf: "PR", localidade: "Maringá", fonte: "log_faixa_localidade"},
+		{name: "apenas faixa de UF", cep: "69900001", uf: "AC", fonte: "log_faixa_uf"},
+		{name: "fora das faixas", cep: "01001000", notFound: true},
+	}
+
+	value := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := db.GetCepFaixa(test.cep)
+
+			var notFound *types.CepNotFoundError
+			if test.notFound {
+				if !errors.As(err, &notFound) {
+					t.Fatalf("esperado CEP não encontrado, obtido %+v, %v", response, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []string{response.UF, value(response.Localidade), response.Cep, value(response.Bairro), response.Fonte}
+			want := []string{test.uf, test.localidade, test.cep, test.bairro, test.fonte}
+			if !slices.Equal(got, want) {
+				t.Errorf("esperado %q, obtido %q", want, got)
+			}
+		})
+	}
+}
+
+func TestCepHistoricoUsesUTC(t *testing.T) {
+	db := newTestDB(t, map[string][][]any{"LOG_LOCALIDADE.TXT": {maringa}})
+
+	antes := time.Now().UTC().Truncate(time.Millisecond)
+	abertos, encerrados, err := db.UpdateCepHistorico("25041")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if abertos != 1 || encerrados != 0 {
+		t.Fatalf("esperada 1 versão aberta e nenhuma encerrada, obtido %d e %d", abertos, encerrados)
+	}
+
+	historico, err := db.GetCepHistorico("87000000", antes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if historico.ValidoDe.Location() != time.UTC || historico.ValidoDe.Before(antes) || historico.ValidoDe.After(time.Now().UTC()) {
+		t.Errorf("valido_de esperado em UTC a partir de %s, obtido %s", antes, historico.ValidoDe)
+	}
+
+	if historico.VersaoEDNE != "25041" || historico.ValidoAte != nil {
+		t.Errorf("esperada versão 25041 vigente, obtido %+v", historico)
+	}
+
+	var notFound *types.CepNotFoundError
+	if _, err := db.GetCepHistorico("87000000", antes.AddDate(0, 0, -1)); !errors.As(err, &notFound) {
+		t.Errorf("esperado CEP não encontrado no dia anterior à importação, obtido %v", err)
+	}
+}
+
+func TestCepHistoricoKeepsCepWithoutLocalidade(t *testing.T) {
+	// O grande usuário de Sarandi herda loc_no_abrev nulo, como em cep_enderecos.
+	db := newTestDB(t, map[string][][]any{
+		"LOG_LOCALIDADE.TXT": {{"2", "PR", "Sarandi", nil, "0", "M", nil, nil, "4126256"}},
+		"LOG_BAIRRO.TXT":     {{"10", "PR", "2", "Centro", "Centro"}},
+		"LOG_GRANDE_USUARIO.TXT": {
+			{"2", "PR", "2", "10", nil, "Prefeitura de Sarandi", "Rua José Emiliano, 1", "87111971", nil},
+		},
+	})
+
+	if _, _, err := db.UpdateCepHistorico("25041"); err != nil {
+		t.Fatal(err)
+	}
+
+	historico, err := db.GetCepHistorico("87111971", time.Now().UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if historico.Localidade != nil {
+		t.Errorf("localidade esperada nula, obtida %q", *historico.Localidade)
+	}
+
+	if historico.Fonte != "log_grande_usuario" {
+		t.Errorf("fonte esperada log_grande_usuario, obtida %q", historico.Fonte)
+	}
+}
+
+func TestExistsImportacaoVersao(t *testing.T) {
+	tests := []struct {
+		situacao string
+		exists   bool
+	}{
+		{situacao: immu.IMPORTACAO_CONCLUIDA, exists: true},
+		{situacao: immu.IMPORTACAO_PENDENTE, exists: true},
+		{situacao: immu.IMPORTACAO_FALHA, exists: false},
+		{situacao: immu.IMPORTACAO_CANCELADA, exists: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.situacao, func(t *testing.T) {
+			db := newTestDB(t, nil)
+
+			_, err := db.InsertImportacaoRelatorio(types.ImportacaoRelatorio{
+				Tipo:       immu.IMPORTACAO_DELTA,
+				Situacao:   test.situacao,
+				VersaoEDNE: "25042",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			exists, err := db.ExistsImportacaoVersao(immu.IMPORTACAO_DELTA, "25042")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if exists != test.exists {
+				t.Errorf("versão importada esperada %v, obtida %v", test.exists, exists)
+			}
+		})
+	}
+}
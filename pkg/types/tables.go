@@ -0,0 +1,361 @@
+package types
+
+import (
+	"slices"
+	"strings"
+)
+
+type Column struct {
+	Name     string
+	Size     int
+	Numeric  bool
+	Required bool
+}
+
+type Reference struct {
+	Column       string
+	Table        string
+	TargetColumn string
+}
+
+type Table struct {
+	Name       string
+	File       string
+	Columns    []Column
+	PrimaryKey []string
+	References []Reference
+}
+
+func (t Table) ColumnNames() []string {
+	names := make([]string, len(t.Columns))
+	for i, column := range t.Columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+func (t Table) Column(name string) (Column, bool) {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+// Colunas de CEP e de referência usadas nas consultas que não são cobertas
+// pelo início da chave primária e precisam de um índice secundário.
+func (t Table) IndexedColumns() []string {
+	var columns []string
+	add := func(column string) {
+		if column != t.PrimaryKey[0] && !slices.Contains(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+
+	if slices.Contains(t.ColumnNames(), "cep") {
+		add("cep")
+	}
+	for _, reference := range t.References {
+		add(reference.Column)
+	}
+	return columns
+}
+
+func text(name string, size int, required bool) Column {
+	return Column{Name: name, Size: size, Required: required}
+}
+
+func number(name string, required bool) Column {
+	return Column{Name: name, Size: 8, Numeric: true, Required: required}
+}
+
+func localidade(column string) Reference {
+	return Reference{Column: column, Table: "log_localidade", TargetColumn: "loc_nu"}
+}
+
+func bairro(column string) Reference {
+	return Reference{Column: column, Table: "log_bairro", TargetColumn: "bai_nu"}
+}
+
+func logradouro(column string) Reference {
+	return Reference{Column: column, Table: "log_logradouro", TargetColumn: "log_nu"}
+}
+
+var CorreiosTables = []Table{
+	{
+		Name: "ect_pais",
+		File: "ECT_PAIS.TXT",
+		Columns: []Column{
+			text("pai_sg", 2, true),
+			text("pai_sg_alternativa", 3, true),
+			text("pai_no_portugues", 100, true),
+			text("pai_no_ingles", 100, true),
+			text("pai_no_frances", 100, true),
+			text("pai_abreviatura", 100, true),
+		},
+		PrimaryKey: []string{"pai_sg"},
+	},
+	{
+		Name: "log_faixa_uf",
+		File: "LOG_FAIXA_UF.TXT",
+		Columns: []Column{
+			text("ufe_sg", 2, true),
+			text("ufe_cep_ini", 8, true),
+			text("ufe_cep_fim", 8, true),
+		},
+		PrimaryKey: []string{"ufe_sg", "ufe_cep_ini"},
+	},
+	{
+		Name: "log_localidade",
+		File: "LOG_LOCALIDADE.TXT",
+		Columns: []Column{
+			number("loc_nu", true),
+			text("ufe_sg", 2, true),
+			text("loc_no", 72, true),
+			text("cep", 8, false),
+			text("loc_in_sit", 1, true),
+			text("loc_in_tipo_loc", 1, true),
+			number("loc_nu_sub", false),
+			text("loc_no_abrev", 36, false),
+			text("mun_nu", 7, false),
+		},
+		PrimaryKey: []string{"loc_nu"},
+		References: []Reference{localidade("loc_nu_sub")},
+	},
+	{
+		Name: "log_var_loc",
+		File: "LOG_VAR_LOC.TXT",
+		Columns: []Column{
+			number("loc_nu", true),
+			number("val_nu", true),
+			text("val_tx", 72, true),
+		},
+		PrimaryKey: []string{"loc_nu", "val_nu"},
+		References: []Reference{localidade("loc_nu")},
+	},
+	{
+		Name: "log_faixa_localidade",
+		File: "LOG_FAIXA_LOCALIDADE.TXT",
+		Columns: []Column{
+			number("loc_nu", true),
+			text("loc_cep_ini", 8, true),
+			text("loc_cep_fim", 8, true),
+			text("loc_tipo_faixa", 1, true),
+		},
+		PrimaryKey: []string{"loc_nu", "loc_cep_ini", "loc_tipo_faixa"},
+		References: []Reference{localidade("loc_nu")},
+	},
+	{
+		Name: "log_bairro",
+		File: "LOG_BAIRRO.TXT",
+		Columns: []Column{
+			number("bai_nu", true),
+			text("ufe_sg", 2, true),
+			number("loc_nu", true),
+			text("bai_no", 72, true),
+			text("bai_no_abrev", 36, false),
+		},
+		PrimaryKey: []string{"bai_nu"},
+		References: []Reference{localidade("loc_nu")},
+	},
+	{
+		Name: "log_var_bai",
+		File: "LOG_VAR_BAI.TXT",
+		Columns: []Column{
+			number("bai_nu", true),
+			text("vdb_nu", 2, true),
+			text("vdb_tx", 72, true),
+		},
+		PrimaryKey: []string{"bai_nu", "vdb_nu"},
+		References: []Reference{bairro("bai_nu")},
+	},
+	{
+		Name: "log_faixa_bairro",
+		File: "LOG_FAIXA_BAIRRO.TXT",
+		Columns: []Column{
+			number("bai_nu", true),
+			text("fcb_cep_ini", 8, true),
+			text("fcb_cep_fim", 8, true),
+		},
+		PrimaryKey: []string{"bai_nu", "fcb_cep_ini"},
+		References: []Reference{bairro("bai_nu")},
+	},
+	{
+		Name: "log_cpc",
+		File: "LOG_CPC.TXT",
+		Columns: []Column{
+			number("cpc_nu", true),
+			text("ufe_sg", 2, true),
+			number("loc_nu", true),
+			text("cpc_no", 72, true),
+			text("cpc_endereco", 100, true),
+			text("cep", 8, true),
+		},
+		PrimaryKey: []string{"cpc_nu"},
+		References: []Reference{localidade("loc_nu")},
+	},
+	{
+		Name: "log_faixa_cpc",
+		File: "LOG_FAIXA_CPC.TXT",
+		Columns: []Column{
+			number("cpc_nu", true),
+			text("cpc_inicial", 6, true),
+			text("cpc_final", 6, true),
+		},
+		PrimaryKey: []string{"cpc_nu", "cpc_inicial"},
+		References: []Reference{{Column: "cpc_nu", Table: "log_cpc", TargetColumn: "cpc_nu"}},
+	},
+	{
+		Name: "log_logradouro",
+		File: "LOG_LOGRADOURO_*.TXT",
+		Columns: []Column{
+			number("log_nu", true),
+			text("ufe_sg", 2, true),
+			number("loc_nu", true),
+			number("bai_nu_ini", true),
+			number("bai_nu_fim", false),
+			text("log_no", 100, true),
+			text("log_complemento", 100, false),
+			text("cep", 8, true),
+			text("tlo_tx", 100, true),
+			text("log_sta_tlo", 1, false),
+			text("log_no_abrev", 100, false),
+		},
+		PrimaryKey: []string{"log_nu"},
+		References: []Reference{localidade("loc_nu"), bairro("bai_nu_ini"), bairro("bai_nu_fim")},
+	},
+	{
+		Name: "log_var_log",
+		File: "LOG_VAR_LOG.TXT",
+		Columns: []Column{
+			number("log_nu", true),
+			number("vlo_nu", true),
+			text("tlo_tx", 36, true),
+			text("vlo_tx", 150, true),
+		},
+		PrimaryKey: []string{"log_nu", "vlo_nu"},
+		References: []Reference{logradouro("log_nu")},
+	},
+	{
+		Name: "log_num_sec",
+		File: "LOG_NUM_SEC.TXT",
+		Columns: []Column{
+			number("log_nu", true),
+			text("sec_nu_ini", 10, true),
+			text("sec_nu_fim", 10, true),
+			text("sec_in_lado", 1, true),
+		},
+		PrimaryKey: []string{"log_nu"},
+		References: []Reference{logradouro("log_nu")},
+	},
+	{
+		Name: "log_grande_usuario",
+		File: "LOG_GRANDE_USUARIO.TXT",
+		Columns: []Column{
+			number("gru_nu", true),
+			text("ufe_sg", 2, true),
+			number("loc_nu", true),
+			number("bai_nu", true),
+			number("log_nu", false),
+			text("gru_no", 255, true),
+			text("gru_endereco", 255, true),
+			text("cep", 8, true),
+			text("gru_no_abrev", 255, false),
+		},
+		PrimaryKey: []string{"gru_nu"},
+		References: []Reference{localidade("loc_nu"), bairro("bai_nu"), logradouro("log_nu")},
+	},
+	{
+		Name: "log_unid_oper",
+		File: "LOG_UNID_OPER.TXT",
+		Columns: []Column{
+			number("uop_nu", true),
+			text("ufe_sg", 2, true),
+			number("loc_nu", true),
+			number("bai_nu", true),
+			number("log_nu", false),
+			text("uop_no", 100, true),
+			text("uop_endereco", 100, true),
+			text("cep", 8, true),
+			text("uop_in_cp", 1, true),
+			text("uop_no_abrev", 100, false),
+		},
+		PrimaryKey: []string{"uop_nu"},
+		References: []Reference{localidade("loc_nu"), bairro("bai_nu"), logradouro("log_nu")},
+	},
+	{
+		Name: "log_faixa_uop",
+		File: "LOG_FAIXA_UOP.TXT",
+		Columns: []Column{
+			number("uop_nu", true),
+			number("fnc_inicial", true),
+			number("fnc_final", true),
+		},
+		PrimaryKey: []string{"uop_nu", "fnc_inicial"},
+		References: []Reference{{Column: "uop_nu", Table: "log_unid_oper", TargetColumn: "uop_nu"}},
+	},
+}
+
+var CepEnderecosTable = Table{
+	Name: "cep_enderecos",
+	Columns: []Column{
+		text("cep", 8, true),
+		text("uf", 2, true),
+		text("localidade", 72, true),
+		text("ibge", 7, false),
+		text("bairro", 72, false),
+		text("complemento", 100, false),
+		text("logradouro", 255, false),
+		text("nome", 255, false),
+		text("fonte", 30, true),
+	},
+	PrimaryKey: []string{"cep"},
+}
+
+func TableByName(name string) (Table, bool) {
+	if name == CepEnderecosTable.Name {
+		return CepEnderecosTable, true
+	}
+
+	for _, table := range CorreiosTables {
+		if table.Name == name {
+			return table, true
+		}
+	}
+
+	return Table{}, false
+}
+
+func TableForFile(fileName string) (Table, bool) {
+	if index := tableIndexForFile(fileName); index >= 0 {
+		return CorreiosTables[index], true
+	}
+	return Table{}, false
+}
+
+// Posição do arquivo na ordem de dependência: CorreiosTables lista cada tabela
+// depois das tabelas que ela referencia. Arquivos desconhecidos ficam ao final.
+func FileOrder(fileName string) int {
+	if index := tableIndexForFile(fileName); index >= 0 {
+		return index
+	}
+	return len(CorreiosTables)
+}
+
+func tableIndexForFile(fileName string) int {
+	name := strings.TrimPrefix(strings.ToUpper(fileName), "DELTA_")
+
+	for i, table := range CorreiosTables {
+		if table.File == name {
+			return i
+		}
+
+		prefix, isPattern := strings.CutSuffix(table.File, "_*.TXT")
+		if isPattern && strings.HasPrefix(name, prefix) && strings.HasSuffix(name, ".TXT") {
+			return i
+		}
+	}
+
+	return -1
+}
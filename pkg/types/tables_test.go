@@ -0,0 +1,66 @@
+package types
+
+import "testing"
+
+func TestCorreiosTablesDependencyOrder(t *testing.T) {
+	position := make(map[string]int)
+	for i, table := range CorreiosTables {
+		position[table.Name] = i
+	}
+
+	for i, table := range CorreiosTables {
+		for _, reference := range table.References {
+			target, ok := position[reference.Table]
+			if !ok {
+				t.Errorf("%s.%s referencia tabela desconhecida %s", table.Name, reference.Column, reference.Table)
+				continue
+			}
+
+			if target > i {
+				t.Errorf("%s.%s referencia %s, que aparece depois no catálogo", table.Name, reference.Column, reference.Table)
+			}
+		}
+	}
+}
+
+func TestFileOrder(t *testing.T) {
+	tests := []struct {
+		before string
+		after  string
+	}{
+		{"DELTA_LOG_LOCALIDADE.TXT", "DELTA_LOG_BAIRRO.TXT"},
+		{"DELTA_LOG_BAIRRO.TXT", "DELTA_LOG_LOGRADOURO_PR.TXT"},
+		{"DELTA_LOG_LOGRADOURO_SP.TXT", "DELTA_LOG_NUM_SEC.TXT"},
+		{"LOG_FAIXA_UF.TXT", "LOG_LOCALIDADE.TXT"},
+		{"DELTA_LOG_UNID_OPER.TXT", "DELTA_DESCONHECIDO.TXT"},
+	}
+
+	for _, test := range tests {
+		if FileOrder(test.before) >= FileOrder(test.after) {
+			t.Errorf("%s deveria ser aplicado antes de %s", test.before, test.after)
+		}
+	}
+}
+
+func TestTableForFile(t *testing.T) {
+	tests := []struct {
+		fileName string
+		table    string
+		ok       bool
+	}{
+		{"LOG_LOCALIDADE.TXT", "log_localidade", true},
+		{"DELTA_LOG_LOCALIDADE.TXT", "log_localidade", true},
+		{"log_logradouro_pr.txt", "log_logradouro", true},
+		{"DELTA_LOG_LOGRADOURO_RS.TXT", "log_logradouro", true},
+		{"ECT_PAIS.TXT", "ect_pais", true},
+		{"LOG_LOGRADOURO.PDF", "", false},
+		{"LEIAME.TXT", "", false},
+	}
+
+	for _, test := range tests {
+		table, ok := TableForFile(test.fileName)
+		if ok != test.ok || table.Name != test.table {
+			t.Errorf("TableForFile(%q) = %q, %v; esperado %q, %v", test.fileName, table.Name, ok, test.table, test.ok)
+		}
+	}
+}
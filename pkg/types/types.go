@@ -2,6 +2,8 @@ package types
 
 import (
 	"context"
+	"fmt"
+	"io/fs"
 	"time"
 )
 
@@ -11,41 +13,128 @@ type Storage interface {
 	Version() (string, error)
 	CreateCorreiosSchema() error
 	CreateCorreiosSql() error
+	SwapCorreiosSchema() error
+	DiscardCorreiosSql() error
 	GetTotalRecords() (int, error)
 	GetTotalCEPs() (int, error)
-	BulkInsertFile(fileName string, rows [][]any) error
+	BulkInsertFile(ctx context.Context, fileName string, rows [][]any) error
+	BeginDelta(ctx context.Context) (DeltaTx, error)
 	GetCep(cep string) (CepResponse, error)
-	InsertImportacaoRelatorio(input ImportacaoRelatorio) error
+	GetCepFaixa(cep string) (CepResponse, error)
+	SearchCep(input BuscaCep) ([]CepResponse, error)
+	GetCepNumero(input BuscaNumero) (CepResponse, error)
+	GetCepHistorico(cep string, data time.Time) (CepHistorico, error)
+	UpdateCepHistorico(versao string) (int, int, error)
+	InsertImportacaoRelatorio(input ImportacaoRelatorio) (int, error)
+	ListImportacaoRelatorio(limite int) ([]ImportacaoRelatorio, error)
+	InsertImportacaoArquivos(importacaoID int, arquivos []ImportacaoArquivo) error
+	ListImportacaoArquivos(importacaoID int) ([]ImportacaoArquivo, error)
+	CopyCorreiosTables(tables []Table) error
+	ExistsImportacaoVersao(tipo string, versao string) (bool, error)
+	CheckIntegrity() ([]IntegrityIssue, error)
+	CreateForeignKeys() error
+	CreateIndexes(ctx context.Context, concurrently bool) ([]IndexBuild, error)
+	CreateCepEnderecos(ctx context.Context) error
+	RefreshCepEnderecos(ctx context.Context) error
+	StreamTable(ctx context.Context, table Table, handle func(row []any) error) error
+}
+
+// Transação única em que todos os arquivos de uma atualização delta são
+// aplicados: uma falha ou interrupção descarta o delta inteiro.
+type DeltaTx interface {
+	ApplyDelta(fileName string, rows [][]any) error
+	Commit() error
+	Rollback() error
 }
 
 type Counter struct {
 	Increment int
 	Error     error
+	Arquivo   *ImportacaoArquivo
 }
 
 type JobTools struct {
 	Ctx         context.Context
 	Database    Storage
-	BasePath    string
+	Source      fs.FS
 	CounterChan chan<- Counter
+	BatchSize   int
+	Workers     chan struct{}
 }
 
 type Processes func(string, JobTools)
 
 type CepResponse struct {
-	uf          string
-	localidade  string
-	cep         string
-	ibge        string
-	bairro      *string
-	complemento *string
-	logradouro  *string
+	UF          string  `json:"uf" db:"uf"`
+	Localidade  *string `json:"localidade" db:"localidade"`
+	Cep         string  `json:"cep" db:"cep"`
+	IBGE        *string `json:"ibge" db:"ibge"`
+	Bairro      *string `json:"bairro" db:"bairro"`
+	Complemento *string `json:"complemento" db:"complemento"`
+	Logradouro  *string `json:"logradouro" db:"logradouro"`
+	Nome        *string `json:"nome" db:"nome"`
+	Fonte       string  `json:"fonte" db:"fonte"`
+}
+
+type CepHistorico struct {
+	CepResponse
+	VersaoEDNE string     `json:"versao_edne" db:"versao_edne"`
+	ValidoDe   time.Time  `json:"valido_de" db:"valido_de"`
+	ValidoAte  *time.Time `json:"valido_ate" db:"valido_ate"`
+}
+
+type BuscaCep struct {
+	UF         string
+	Localidade string
+	Logradouro string
+	Limite     int
+}
+
+type BuscaNumero struct {
+	UF         string
+	Localidade string
+	Logradouro string
+	Numero     int
+}
+
+type CepNotFoundError struct {
+	Cep string
+}
+
+func (e *CepNotFoundError) Error() string {
+	return fmt.Sprintf("CEP %s não encontrado", e.Cep)
 }
 
 type ImportacaoRelatorio struct {
+	ID             int
+	ExecutadoEm    time.Time
+	Tipo           string
+	Situacao       string
 	TotalRegistros int
 	TotalCeps      int
 	VersaoEDNE     string
 	Duracao        time.Duration
 	Observacoes    string
 }
+
+type ImportacaoArquivo struct {
+	Arquivo   string
+	Linhas    int
+	Registros int
+	Bytes     int64
+	Checksum  string
+	Duracao   time.Duration
+	Erro      string
+}
+
+type IntegrityIssue struct {
+	Table     string
+	Reference Reference
+	Orphans   int
+}
+
+type IndexBuild struct {
+	Table   string
+	Index   string
+	Duracao time.Duration
+}
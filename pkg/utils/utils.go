@@ -4,9 +4,14 @@ import (
 	"log"
 	"os"
 	"strings"
+	"unicode"
 
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
 func GetCWD() string {
@@ -22,8 +27,10 @@ func FormatNumber(n int) string {
 	return p.Sprintf("%d", n)
 }
 
+// As colunas de ect_pais são obrigatórias e vêm vazias em alguns países, então
+// o vazio é mantido para o arquivo da base e para o DELTA_ECT_PAIS.
 func HandleEmpty(field string, fileName string) any {
-	if fileName == "ECT_PAIS.TXT" && field == "" {
+	if table, ok := types.TableForFile(fileName); ok && table.Name == "ect_pais" && field == "" {
 		return field
 	}
 
@@ -42,3 +49,67 @@ func GetHostname() string {
 	}
 	return hostname
 }
+
+func NormalizeCep(cep string) (string, bool) {
+	cep = strings.ReplaceAll(strings.TrimSpace(cep), "-", "")
+	if len(cep) != 8 || !IsDigits(cep) {
+		return "", false
+	}
+
+	return cep, true
+}
+
+func IsDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Equivalente em Go ao correios.normaliza do PostgreSQL (lower + unaccent).
+func NormalizeText(value string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	normalized, _, err := transform.String(t, value)
+	if err != nil {
+		normalized = value
+	}
+	return strings.ToLower(strings.Join(strings.Fields(normalized), " "))
+}
+
+// Similaridade por trigramas no mesmo formato do pg_trgm: cada palavra é
+// prefixada com dois espaços e sufixada com um antes de gerar os trigramas.
+func Similarity(a string, b string) float64 {
+	left, right := trigrams(a), trigrams(b)
+	if len(left) == 0 || len(right) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for trigram := range left {
+		if right[trigram] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(left)+len(right)-shared)
+}
+
+func trigrams(value string) map[string]bool {
+	result := make(map[string]bool)
+	words := strings.FieldsFunc(value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			result[string(padded[i:i+3])] = true
+		}
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHandleEmpty(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		file  string
+		want  any
+	}{
+		{name: "campo preenchido", field: " Centro ", file: "LOG_BAIRRO.TXT", want: "Centro"},
+		{name: "campo vazio", field: "", file: "LOG_BAIRRO.TXT", want: nil},
+		{name: "ect_pais mantém o vazio", field: "", file: "ECT_PAIS.TXT", want: ""},
+		{name: "delta de ect_pais mantém o vazio", field: "", file: "DELTA_ECT_PAIS.TXT", want: ""},
+		{name: "nome do arquivo em minúsculas", field: "", file: "ect_pais.txt", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := HandleEmpty(test.field, test.file); got != test.want {
+				t.Errorf("esperado %#v, obtido %#v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeCep(t *testing.T) {
+	tests := []struct {
+		cep  string
+		want string
+		ok   bool
+	}{
+		{cep: "87020025", want: "87020025", ok: true},
+		{cep: " 87020-025 ", want: "87020025", ok: true},
+		{cep: "8702002", ok: false},
+		{cep: "870200250", ok: false},
+		{cep: "8702002A", ok: false},
+		{cep: "", ok: false},
+	}
+
+	for _, test := range tests {
+		got, ok := NormalizeCep(test.cep)
+		if got != test.want || ok != test.ok {
+			t.Errorf("NormalizeCep(%q): esperado %q, %v, obtido %q, %v", test.cep, test.want, test.ok, got, ok)
+		}
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	tests := map[string]string{
+		"Avenida  Brasil":    "avenida brasil",
+		"MARINGÁ":            "maringa",
+		" São João do Ivaí ": "sao joao do ivai",
+		"Conceição":          "conceicao",
+	}
+
+	for value, want := range tests {
+		if got := NormalizeText(value); got != want {
+			t.Errorf("NormalizeText(%q): esperado %q, obtido %q", value, want, got)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want float64
+	}{
+		{a: "brasil", b: "brasil", want: 1},
+		// Mesmo resultado de similarity('word', 'two words') no pg_trgm.
+		{a: "word", b: "two words", want: 4.0 / 11},
+		{a: "brasil", b: "", want: 0},
+		{a: "abc", b: "xyz", want: 0},
+	}
+
+	for _, test := range tests {
+		if got := Similarity(test.a, test.b); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("Similarity(%q, %q): esperado %f, obtido %f", test.a, test.b, test.want, got)
+		}
+	}
+}
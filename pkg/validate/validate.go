@@ -0,0 +1,140 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	immu "github.com/diegodario88/importador-cep-correios/pkg/constants"
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+	"github.com/diegodario88/importador-cep-correios/pkg/utils"
+	"github.com/diegodario88/importador-cep-correios/pkg/workers"
+)
+
+const maxSamples = 5
+
+var ufs = map[string]bool{
+	"AC": true, "AL": true, "AM": true, "AP": true, "BA": true, "CE": true, "DF": true,
+	"ES": true, "GO": true, "MA": true, "MG": true, "MS": true, "MT": true, "PA": true,
+	"PB": true, "PE": true, "PI": true, "PR": true, "RJ": true, "RN": true, "RO": true,
+	"RR": true, "RS": true, "SC": true, "SE": true, "SP": true, "TO": true,
+}
+
+type FileReport struct {
+	File    string
+	Lines   int
+	Errors  int
+	Samples []string
+}
+
+func (r *FileReport) add(line int, issue string) {
+	r.Errors++
+	if len(r.Samples) < maxSamples {
+		r.Samples = append(r.Samples, fmt.Sprintf("linha %d: %s", line, issue))
+	}
+}
+
+func Files(source fs.FS, delta bool) ([]string, error) {
+	patterns := []string{"DELTA_*.TXT"}
+	if !delta {
+		patterns = patterns[:0]
+		for _, table := range types.CorreiosTables {
+			patterns = append(patterns, table.File)
+		}
+	}
+
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(source, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar arquivos: %w", err)
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("padrão %s não encontrou arquivos", pattern)
+		}
+
+		for _, match := range matches {
+			files = append(files, path.Base(match))
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+func File(ctx context.Context, source fs.FS, fileName string) (FileReport, error) {
+	report := FileReport{File: fileName}
+
+	table, ok := types.TableForFile(fileName)
+	if !ok {
+		return report, fmt.Errorf("unknown file name: %s", fileName)
+	}
+
+	delta := strings.HasPrefix(strings.ToUpper(fileName), "DELTA_")
+
+	err := workers.ReadRows(ctx, source, fileName, func(row []any) error {
+		report.Lines++
+		for _, issue := range checkRow(table, row, delta) {
+			report.add(report.Lines, issue)
+		}
+		return nil
+	})
+
+	return report, err
+}
+
+func checkRow(table types.Table, row []any, delta bool) []string {
+	expected := len(table.Columns)
+	if delta {
+		expected++
+	}
+
+	if len(row) != expected {
+		return []string{fmt.Sprintf("esperadas %d colunas, encontradas %d", expected, len(row))}
+	}
+
+	var issues []string
+	if delta {
+		operation, _ := row[expected-1].(string)
+		if operation != immu.DELTA_INSERT && operation != immu.DELTA_UPDATE && operation != immu.DELTA_DELETE {
+			issues = append(issues, fmt.Sprintf("operação delta inválida %q", operation))
+		}
+	}
+
+	for i, column := range table.Columns {
+		value, _ := row[i].(string)
+		if row[i] == nil {
+			if column.Required {
+				issues = append(issues, fmt.Sprintf("coluna %s é obrigatória", column.Name))
+			}
+			continue
+		}
+
+		if utf8.RuneCountInString(value) > column.Size {
+			issues = append(issues, fmt.Sprintf("coluna %s excede %d caracteres: %q", column.Name, column.Size, value))
+		}
+
+		if column.Numeric && !utils.IsDigits(value) {
+			issues = append(issues, fmt.Sprintf("coluna %s não é numérica: %q", column.Name, value))
+		}
+
+		if column.Name == "ufe_sg" && !ufs[value] {
+			issues = append(issues, fmt.Sprintf("UF inválida: %q", value))
+		}
+
+		if isCepColumn(column.Name) && (len(value) != 8 || !utils.IsDigits(value)) {
+			issues = append(issues, fmt.Sprintf("coluna %s não é um CEP de 8 dígitos: %q", column.Name, value))
+		}
+	}
+
+	return issues
+}
+
+func isCepColumn(name string) bool {
+	return name == "cep" || strings.Contains(name, "_cep_")
+}
@@ -0,0 +1,107 @@
+package validate
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		lines   int
+		issues  []string
+	}{
+		{
+			name:    "linhas válidas",
+			file:    "LOG_BAIRRO.TXT",
+			content: "10@PR@1@Centro@Centro\n11@PR@1@Zona 7@\n",
+			lines:   2,
+		},
+		{
+			name:    "quantidade de colunas",
+			file:    "LOG_BAIRRO.TXT",
+			content: "10@PR@1@Centro\n",
+			lines:   1,
+			issues:  []string{"linha 1: esperadas 5 colunas, encontradas 4"},
+		},
+		{
+			name:    "campo obrigatório, numérico e UF",
+			file:    "LOG_BAIRRO.TXT",
+			content: "1A@XX@1@@Centro\n",
+			lines:   1,
+			issues: []string{
+				`linha 1: coluna bai_nu não é numérica: "1A"`,
+				`linha 1: UF inválida: "XX"`,
+				"linha 1: coluna bai_no é obrigatória",
+			},
+		},
+		{
+			name:    "CEP e tamanho do campo",
+			file:    "LOG_FAIXA_UF.TXT",
+			content: "PR@8000000@879999990\n",
+			lines:   1,
+			issues: []string{
+				`linha 1: coluna ufe_cep_ini não é um CEP de 8 dígitos: "8000000"`,
+				`linha 1: coluna ufe_cep_fim excede 8 caracteres: "879999990"`,
+				`linha 1: coluna ufe_cep_fim não é um CEP de 8 dígitos: "879999990"`,
+			},
+		},
+		{
+			name:    "operação delta",
+			file:    "DELTA_LOG_BAIRRO.TXT",
+			content: "10@PR@1@Centro@Centro@INS\n11@PR@1@Zona 7@Zona 7@XXX\n12@PR@1@Zona 5@Zona 5\n",
+			lines:   3,
+			issues: []string{
+				`linha 2: operação delta inválida "XXX"`,
+				"linha 3: esperadas 6 colunas, encontradas 5",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := fstest.MapFS{test.file: {Data: []byte(test.content)}}
+
+			report, err := File(context.Background(), source, test.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if report.Lines != test.lines {
+				t.Errorf("linhas esperadas %d, obtidas %d", test.lines, report.Lines)
+			}
+
+			if report.Errors != len(test.issues) || !slices.Equal(report.Samples, test.issues) {
+				t.Errorf("erros esperados:\n%s\nobtidos (%d):\n%s",
+					strings.Join(test.issues, "\n"), report.Errors, strings.Join(report.Samples, "\n"))
+			}
+		})
+	}
+}
+
+func TestFiles(t *testing.T) {
+	source := fstest.MapFS{
+		"DELTA_LOG_BAIRRO.TXT":     {},
+		"DELTA_LOG_LOCALIDADE.TXT": {},
+		"LEIAME.TXT":               {},
+	}
+
+	files, err := Files(source, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"DELTA_LOG_BAIRRO.TXT", "DELTA_LOG_LOCALIDADE.TXT"}
+	if !slices.Equal(files, want) {
+		t.Errorf("arquivos esperados %v, obtidos %v", want, files)
+	}
+
+	if _, err := Files(source, false); err == nil {
+		t.Error("esperado erro para base completa sem os arquivos das tabelas")
+	}
+}
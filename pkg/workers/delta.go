@@ -0,0 +1,69 @@
+package workers
+
+import (
+	"cmp"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+
+	immu "github.com/diegodario88/importador-cep-correios/pkg/constants"
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+)
+
+// Todos os arquivos são aplicados em uma única transação, na ordem de
+// dependência das tabelas; qualquer erro ou cancelamento descarta o delta.
+func Delta(filePattern string, tools types.JobTools) {
+	counter := types.Counter{
+		Increment: 1,
+		Error:     nil,
+	}
+
+	matches, err := fs.Glob(tools.Source, filePattern)
+	if err != nil {
+		counter.Error = fmt.Errorf("erro ao buscar arquivos: %w", err)
+		tools.CounterChan <- counter
+		return
+	}
+
+	if len(matches) == 0 {
+		counter.Error = fmt.Errorf("padrão %s não encontrou arquivos", filePattern)
+		tools.CounterChan <- counter
+		return
+	}
+
+	slices.SortStableFunc(matches, func(a, b string) int {
+		return cmp.Compare(types.FileOrder(path.Base(a)), types.FileOrder(path.Base(b)))
+	})
+
+	batchSize := tools.BatchSize
+	if batchSize <= 0 {
+		batchSize = immu.ONE_THOUSAND_BATCH_SIZE
+	}
+
+	tx, err := tools.Database.BeginDelta(tools.Ctx)
+	if err != nil {
+		counter.Error = err
+		tools.CounterChan <- counter
+		return
+	}
+	defer tx.Rollback()
+
+	for _, filePath := range matches {
+		fileName := path.Base(filePath)
+		err := process(fileName, tools, batchSize, func(rows [][]any) error {
+			return tx.ApplyDelta(fileName, rows)
+		})
+
+		if err != nil {
+			counter.Error = err
+			tools.CounterChan <- counter
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		counter.Error = err
+		tools.CounterChan <- counter
+	}
+}
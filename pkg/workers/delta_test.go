@@ -0,0 +1,135 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+)
+
+type recordedBatch struct {
+	file string
+	rows int
+}
+
+type fakeDeltaTx struct {
+	batches    []recordedBatch
+	failOn     string
+	begins     int
+	committed  bool
+	rolledBack bool
+}
+
+type fakeStorage struct {
+	types.Storage
+	tx *fakeDeltaTx
+}
+
+func (s fakeStorage) BeginDelta(ctx context.Context) (types.DeltaTx, error) {
+	s.tx.begins++
+	return s.tx, nil
+}
+
+func (tx *fakeDeltaTx) ApplyDelta(fileName string, rows [][]any) error {
+	if fileName == tx.failOn {
+		return errors.New("falha ao aplicar")
+	}
+	tx.batches = append(tx.batches, recordedBatch{fileName, len(rows)})
+	return nil
+}
+
+func (tx *fakeDeltaTx) Commit() error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeDeltaTx) Rollback() error {
+	if !tx.committed {
+		tx.rolledBack = true
+	}
+	return nil
+}
+
+func runDelta(t *testing.T, source fstest.MapFS, tx *fakeDeltaTx, batchSize int) []types.Counter {
+	t.Helper()
+
+	counters := make(chan types.Counter)
+	tools := types.JobTools{
+		Ctx:         context.Background(),
+		Database:    fakeStorage{tx: tx},
+		Source:      source,
+		CounterChan: counters,
+		BatchSize:   batchSize,
+	}
+
+	go func() {
+		Delta("DELTA_*.TXT", tools)
+		close(counters)
+	}()
+
+	var received []types.Counter
+	for counter := range counters {
+		received = append(received, counter)
+	}
+	return received
+}
+
+var deltaSource = fstest.MapFS{
+	"DELTA_LOG_LOGRADOURO_PR.TXT": {Data: []byte("1@PR@1@10@@Teste@@87000001@Rua@S@R Teste@INS\n")},
+	"DELTA_LOG_BAIRRO.TXT": {Data: []byte(
+		"10@PR@1@Centro@Centro@INS\n" +
+			"11@PR@1@Zona 7@Zona 7@UPD\n" +
+			"12@PR@1@Zona 5@Zona 5@DEL\n")},
+	"DELTA_LOG_LOCALIDADE.TXT": {Data: []byte("1@PR@Maringá@87000000@0@M@@Maringá@4115200@UPD\n")},
+}
+
+func TestDeltaAppliesFilesInDependencyOrder(t *testing.T) {
+	tx := &fakeDeltaTx{}
+	for _, counter := range runDelta(t, deltaSource, tx, 2) {
+		if counter.Error != nil {
+			t.Fatal(counter.Error)
+		}
+	}
+
+	want := []recordedBatch{
+		{"DELTA_LOG_LOCALIDADE.TXT", 1},
+		{"DELTA_LOG_BAIRRO.TXT", 2},
+		{"DELTA_LOG_BAIRRO.TXT", 1},
+		{"DELTA_LOG_LOGRADOURO_PR.TXT", 1},
+	}
+
+	if !slices.Equal(tx.batches, want) {
+		t.Errorf("lotes esperados %v, obtidos %v", want, tx.batches)
+	}
+
+	if tx.begins != 1 || !tx.committed || tx.rolledBack {
+		t.Errorf("esperada uma única transação confirmada, obtido begins=%d commit=%v rollback=%v",
+			tx.begins, tx.committed, tx.rolledBack)
+	}
+}
+
+func TestDeltaRollsBackOnFailure(t *testing.T) {
+	tx := &fakeDeltaTx{failOn: "DELTA_LOG_BAIRRO.TXT"}
+
+	var failure error
+	for _, counter := range runDelta(t, deltaSource, tx, 0) {
+		if counter.Error != nil {
+			failure = counter.Error
+		}
+	}
+
+	if failure == nil {
+		t.Fatal("esperado erro ao aplicar DELTA_LOG_BAIRRO.TXT")
+	}
+
+	if tx.committed || !tx.rolledBack {
+		t.Errorf("esperado rollback sem commit, obtido commit=%v rollback=%v", tx.committed, tx.rolledBack)
+	}
+
+	if len(tx.batches) != 1 || tx.batches[0].file != "DELTA_LOG_LOCALIDADE.TXT" {
+		t.Errorf("esperado apenas DELTA_LOG_LOCALIDADE.TXT antes da falha, obtido %v", tx.batches)
+	}
+}
@@ -2,7 +2,8 @@ package workers
 
 import (
 	"fmt"
-	"path/filepath"
+	"io/fs"
+	"path"
 	"sync"
 
 	"github.com/diegodario88/importador-cep-correios/pkg/types"
@@ -16,7 +17,7 @@ func Multiple(filePattern string, tools types.JobTools) {
 		Error:     nil,
 	}
 
-	matches, err := filepath.Glob(filepath.Join(tools.BasePath, filePattern))
+	matches, err := fs.Glob(tools.Source, filePattern)
 	if err != nil {
 		counter.Error = fmt.Errorf("erro ao buscar arquivos: %w", err)
 		tools.CounterChan <- counter
@@ -33,7 +34,7 @@ func Multiple(filePattern string, tools types.JobTools) {
 	wg.Add(ufs)
 
 	for _, filePath := range matches {
-		fileName := filepath.Base(filePath)
+		fileName := path.Base(filePath)
 		go func(fileName string) {
 			defer wg.Done()
 			Single(fileName, tools)
@@ -0,0 +1,117 @@
+package workers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+)
+
+const (
+	delimiter          = "@"
+	deltaOperationSize = 3
+)
+
+// Separa uma linha do arquivo eDNE nos campos da tabela, conforme o layout.
+type Parser interface {
+	Fields(line string) ([]string, error)
+}
+
+type DelimitedParser struct{}
+
+func (DelimitedParser) Fields(line string) ([]string, error) {
+	return strings.Split(line, delimiter), nil
+}
+
+// Layout posicional: cada campo ocupa exatamente a quantidade de caracteres
+// do layout, completada com espaços, e toda linha tem o tamanho do registro.
+type FixedWidthParser struct {
+	Widths []int
+}
+
+func (p FixedWidthParser) Fields(line string) ([]string, error) {
+	runes := []rune(strings.TrimRight(line, "\r"))
+	if length := len(runes); length != p.width() {
+		return nil, fmt.Errorf("linha com %d caracteres, registro posicional de %d", length, p.width())
+	}
+
+	fields := make([]string, len(p.Widths))
+	start := 0
+	for i, width := range p.Widths {
+		fields[i] = strings.TrimSpace(string(runes[start : start+width]))
+		start += width
+	}
+	return fields, nil
+}
+
+func (p FixedWidthParser) width() int {
+	total := 0
+	for _, width := range p.Widths {
+		total += width
+	}
+	return total
+}
+
+// Tamanho de cada campo do registro no layout posicional do eDNE, na ordem
+// das colunas da tabela, conforme o Leiame do layout fixo dos Correios.
+// Campos numéricos (NUMBER(8)) ocupam 8 posições.
+var fixedWidthLayouts = map[string][]int{
+	"ect_pais":             {2, 3, 72, 72, 72, 36},
+	"log_faixa_uf":         {2, 8, 8},
+	"log_localidade":       {8, 2, 72, 8, 1, 1, 8, 36, 7},
+	"log_var_loc":          {8, 8, 72},
+	"log_faixa_localidade": {8, 8, 8, 1},
+	"log_bairro":           {8, 2, 8, 72, 36},
+	"log_var_bai":          {8, 2, 72},
+	"log_faixa_bairro":     {8, 8, 8},
+	"log_cpc":              {8, 2, 8, 72, 100, 8},
+	"log_faixa_cpc":        {8, 6, 6},
+	"log_logradouro":       {8, 2, 8, 8, 8, 100, 100, 8, 36, 1, 36},
+	"log_var_log":          {8, 8, 36, 150},
+	"log_num_sec":          {8, 10, 10, 1},
+	"log_grande_usuario":   {8, 2, 8, 8, 8, 72, 100, 8, 36},
+	"log_unid_oper":        {8, 2, 8, 8, 8, 100, 100, 8, 1, 36},
+	"log_faixa_uop":        {8, 8, 8},
+}
+
+// Layout posicional do arquivo; os arquivos DELTA_ trazem a operação (INS,
+// UPD ou DEL) ao final do registro.
+func FixedWidthFor(fileName string) (FixedWidthParser, bool) {
+	table, ok := types.TableForFile(fileName)
+	if !ok {
+		return FixedWidthParser{}, false
+	}
+
+	layout, ok := fixedWidthLayouts[table.Name]
+	if !ok {
+		return FixedWidthParser{}, false
+	}
+
+	widths := slices.Clone(layout)
+	if strings.HasPrefix(strings.ToUpper(fileName), "DELTA_") {
+		widths = append(widths, deltaOperationSize)
+	}
+	return FixedWidthParser{Widths: widths}, true
+}
+
+// Todas as tabelas têm mais de uma coluna, então uma linha delimitada sempre
+// contém @; sem ele, a linha é tratada como posicional e precisa ter o
+// tamanho do registro.
+func DetectParser(fileName string, firstLine string) (Parser, error) {
+	if strings.Contains(firstLine, delimiter) {
+		return DelimitedParser{}, nil
+	}
+
+	fixed, ok := FixedWidthFor(fileName)
+	if !ok {
+		return nil, fmt.Errorf("layout do arquivo %s não reconhecido", fileName)
+	}
+
+	if length := utf8.RuneCountInString(strings.TrimRight(firstLine, "\r")); length != fixed.width() {
+		return nil, fmt.Errorf("layout do arquivo %s não reconhecido: linha sem @ com %d caracteres, registro posicional de %d",
+			fileName, length, fixed.width())
+	}
+	return fixed, nil
+}
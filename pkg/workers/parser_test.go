@@ -0,0 +1,140 @@
+package workers
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/diegodario88/importador-cep-correios/pkg/types"
+)
+
+func TestFixedWidthLayoutsMatchCatalog(t *testing.T) {
+	for _, table := range types.CorreiosTables {
+		layout, ok := fixedWidthLayouts[table.Name]
+		if !ok {
+			t.Errorf("tabela %s sem layout posicional", table.Name)
+			continue
+		}
+
+		if len(layout) != len(table.Columns) {
+			t.Errorf("tabela %s: layout com %d campos, catálogo com %d colunas", table.Name, len(layout), len(table.Columns))
+			continue
+		}
+
+		for i, column := range table.Columns {
+			if layout[i] > column.Size {
+				t.Errorf("tabela %s: campo %s com %d posições excede o tamanho %d da coluna",
+					table.Name, column.Name, layout[i], column.Size)
+			}
+		}
+	}
+}
+
+func TestDetectParser(t *testing.T) {
+	bairro := "10      PR1       Centro" + strings.Repeat(" ", 66) + "Centro" + strings.Repeat(" ", 30)
+
+	tests := []struct {
+		name    string
+		file    string
+		line    string
+		want    Parser
+		wantErr bool
+	}{
+		{name: "delimitado", file: "LOG_BAIRRO.TXT", line: "10@PR@1@Centro@Centro", want: DelimitedParser{}},
+		{name: "posicional", file: "LOG_BAIRRO.TXT", line: bairro, want: FixedWidthParser{Widths: []int{8, 2, 8, 72, 36}}},
+		{name: "posicional com CRLF", file: "LOG_BAIRRO.TXT", line: bairro + "\r", want: FixedWidthParser{Widths: []int{8, 2, 8, 72, 36}}},
+		{name: "posicional delta", file: "DELTA_LOG_BAIRRO.TXT", line: bairro + "INS", want: FixedWidthParser{Widths: []int{8, 2, 8, 72, 36, 3}}},
+		{name: "delta sem a operação", file: "DELTA_LOG_BAIRRO.TXT", line: bairro, wantErr: true},
+		{name: "registro truncado", file: "LOG_BAIRRO.TXT", line: strings.TrimRight(bairro, " "), wantErr: true},
+		{name: "arquivo desconhecido", file: "LOG_DESCONHECIDO.TXT", line: bairro, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parser, err := DetectParser(test.file, test.line)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+
+			if !reflect.DeepEqual(parser, test.want) {
+				t.Errorf("parser esperado %#v, obtido %#v", test.want, parser)
+			}
+		})
+	}
+}
+
+func TestFixedWidthParserFields(t *testing.T) {
+	parser, _ := FixedWidthFor("DELTA_LOG_FAIXA_UF.TXT")
+
+	fields, err := parser.Fields("PR8000000087999999UPD\r")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"PR", "80000000", "87999999", "UPD"}; !slices.Equal(fields, want) {
+		t.Errorf("campos esperados %q, obtidos %q", want, fields)
+	}
+
+	if _, err := parser.Fields("PR8000000087999999UPDX"); err == nil {
+		t.Error("esperado erro para linha maior que o registro")
+	}
+}
+
+func readFixture(t *testing.T, layout string, fileName string) [][]any {
+	t.Helper()
+
+	var rows [][]any
+	err := ReadRows(context.Background(), os.DirFS("testdata/"+layout), fileName, func(row []any) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestReadRowsFixedWidthMatchesDelimited(t *testing.T) {
+	tests := []struct {
+		file  string
+		first []any
+	}{
+		{file: "LOG_LOCALIDADE.TXT", first: []any{"1", "PR", "Maringá", "87000000", "0", "M", nil, "Maringá", "4115200"}},
+		{file: "LOG_BAIRRO.TXT", first: []any{"10", "PR", "1", "Centro", "Centro"}},
+		{file: "DELTA_LOG_BAIRRO.TXT", first: []any{"12", "PR", "1", "Zona 5", "Zona 5", "INS"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			fixo := readFixture(t, "fixo", test.file)
+			delimitado := readFixture(t, "delimitado", test.file)
+
+			if len(fixo) == 0 || !reflect.DeepEqual(fixo[0], test.first) {
+				t.Fatalf("primeira linha esperada %q, obtidas %q", test.first, fixo)
+			}
+
+			if !reflect.DeepEqual(fixo, delimitado) {
+				t.Errorf("layouts divergentes:\nposicional %q\ndelimitado %q", fixo, delimitado)
+			}
+		})
+	}
+}
+
+func TestReadRowsRejectsLineWithWrongLength(t *testing.T) {
+	content, err := os.ReadFile("testdata/fixo/LOG_BAIRRO.TXT")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	truncated := append(content, "12      PR1       Zona 5\r\n"...)
+	source := fstest.MapFS{"LOG_BAIRRO.TXT": {Data: truncated}}
+
+	err = ReadRows(context.Background(), source, "LOG_BAIRRO.TXT", func(row []any) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "registro posicional") {
+		t.Errorf("esperado erro de tamanho do registro, obtido %v", err)
+	}
+}
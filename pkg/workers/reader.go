@@ -0,0 +1,84 @@
+package workers
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/diegodario88/importador-cep-correios/pkg/utils"
+	"golang.org/x/text/encoding/charmap"
+)
+
+type fileStats struct {
+	bytes    int64
+	checksum string
+}
+
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+func ReadRows(ctx context.Context, source fs.FS, fileName string, handle func(row []any) error) error {
+	_, err := readRows(ctx, source, fileName, handle)
+	return err
+}
+
+// O checksum (SHA-256 do arquivo original) só é calculado quando o arquivo é
+// lido até o fim.
+func readRows(ctx context.Context, source fs.FS, fileName string, handle func(row []any) error) (stats fileStats, err error) {
+	file, err := source.Open(fileName)
+	if err != nil {
+		return stats, fmt.Errorf("erro ao abrir arquivo %s: %w", fileName, err)
+	}
+	defer file.Close()
+
+	var read byteCounter
+	hash := sha256.New()
+	defer func() { stats.bytes = int64(read) }()
+
+	decoder := charmap.ISO8859_1.NewDecoder()
+	reader := decoder.Reader(io.TeeReader(file, io.MultiWriter(hash, &read)))
+
+	var parser Parser
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		line := scanner.Text()
+		if parser == nil {
+			if parser, err = DetectParser(fileName, line); err != nil {
+				return stats, err
+			}
+		}
+
+		fields, err := parser.Fields(line)
+		if err != nil {
+			return stats, fmt.Errorf("erro ao ler arquivo %s: %w", fileName, err)
+		}
+		row := make([]any, len(fields))
+
+		for i := range fields {
+			row[i] = utils.HandleEmpty(fields[i], fileName)
+		}
+
+		if err := handle(row); err != nil {
+			return stats, err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return stats, fmt.Errorf("erro ao escanear arquivo: %w", err)
+	}
+
+	stats.checksum = hex.EncodeToString(hash.Sum(nil))
+	return stats, nil
+}
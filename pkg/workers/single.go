@@ -1,86 +1,76 @@
 package workers
 
 import (
-	"bufio"
-	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
+	"time"
 
 	immu "github.com/diegodario88/importador-cep-correios/pkg/constants"
 	"github.com/diegodario88/importador-cep-correios/pkg/types"
-	"github.com/diegodario88/importador-cep-correios/pkg/utils"
-	"golang.org/x/text/encoding/charmap"
 )
 
 func Single(fileName string, tools types.JobTools) {
-	filePath := filepath.Join(tools.BasePath, fileName)
-	counter := types.Counter{
-		Increment: 1,
-		Error:     nil,
+	if tools.Workers != nil {
+		tools.Workers <- struct{}{}
+		defer func() { <-tools.Workers }()
 	}
 
-	_, err := os.Stat(filePath)
-	if err != nil {
-		counter.Error = fmt.Errorf("arquivo %s não encontrado: %w", filePath, err)
-		tools.CounterChan <- counter
-		return
+	batchSize := tools.BatchSize
+	if batchSize <= 0 {
+		batchSize = immu.ONE_THOUSAND_BATCH_SIZE
 	}
 
-	file, err := os.Open(filePath)
+	err := process(fileName, tools, batchSize, func(batch [][]any) error {
+		return tools.Database.BulkInsertFile(tools.Ctx, fileName, batch)
+	})
+
 	if err != nil {
-		counter.Error = fmt.Errorf("erro ao abrir arquivo %s: %w", filePath, err)
-		tools.CounterChan <- counter
-		return
+		tools.CounterChan <- types.Counter{Increment: 1, Error: err}
 	}
-	defer file.Close()
-
-	decoder := charmap.ISO8859_1.NewDecoder()
-	reader := decoder.Reader(file)
+}
 
-	if _, err := file.Seek(0, 0); err != nil {
-		counter.Error = fmt.Errorf("erro ao resetar leitura do arquivo: %w", err)
-		tools.CounterChan <- counter
-		return
+// Além de enviar uma contagem por linha, envia ao final o resumo do arquivo
+// (linhas, registros gravados, bytes, checksum, duração e erro).
+func process(fileName string, tools types.JobTools, batchSize int, flush func([][]any) error) error {
+	start := time.Now()
+	counter := types.Counter{
+		Increment: 1,
+		Error:     nil,
 	}
+	arquivo := types.ImportacaoArquivo{Arquivo: fileName}
 
-	scanner := bufio.NewScanner(reader)
-	const batchSize = immu.ONE_THOUSAND_BATCH_SIZE
-	var batch [][]any
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		fields := strings.Split(line, "@")
-		row := make([]any, len(fields))
-
-		for i := range fields {
-			row[i] = utils.HandleEmpty(fields[i], fileName)
+	write := func(rows [][]any) error {
+		if err := flush(rows); err != nil {
+			return err
 		}
+		arquivo.Registros += len(rows)
+		return nil
+	}
 
+	var batch [][]any
+	stats, err := readRows(tools.Ctx, tools.Source, fileName, func(row []any) error {
+		arquivo.Linhas++
 		batch = append(batch, row)
-		if len(batch) >= batchSize {
-			if err := tools.Database.BulkInsertFile(fileName, batch); err != nil {
-				counter.Error = err
-				tools.CounterChan <- counter
-				return
+		if batchSize > 0 && len(batch) >= batchSize {
+			if err := write(batch); err != nil {
+				return err
 			}
 			batch = batch[:0]
 		}
 
 		tools.CounterChan <- counter
-	}
+		return nil
+	})
 
-	if err := scanner.Err(); err != nil {
-		counter.Error = fmt.Errorf("erro ao escanear arquivo: %w", err)
-		tools.CounterChan <- counter
-		return
+	if err == nil && len(batch) > 0 {
+		err = write(batch)
 	}
 
-	if len(batch) > 0 {
-		if err := tools.Database.BulkInsertFile(fileName, batch); err != nil {
-			counter.Error = err
-			tools.CounterChan <- counter
-			return
-		}
+	arquivo.Bytes = stats.bytes
+	arquivo.Checksum = stats.checksum
+	arquivo.Duracao = time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		arquivo.Erro = err.Error()
 	}
+
+	tools.CounterChan <- types.Counter{Arquivo: &arquivo}
+	return err
 }